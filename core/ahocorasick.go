@@ -0,0 +1,271 @@
+package core
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Dictionary pattern with attached payload (entity ID, type, etc.)
+type ACPattern struct {
+	Text    string
+	Payload interface{}
+}
+
+// Match found by RuneMatcher or WordMatcher
+// From and To are rune offsets for RuneMatcher and word indexes for WordMatcher
+type ACMatch struct {
+	From    int
+	To      int
+	Pattern int // index of pattern in dictionary
+	Payload interface{}
+}
+
+// Matching options
+type ACOptions struct {
+	// Ignore case differences (simple Unicode case folding)
+	CaseInsensitive bool
+	// Report only matches that aren't parts of longer words
+	// (ignored by WordMatcher which always matches whole words)
+	WholeWords bool
+}
+
+// Node of Aho-Corasick automaton
+type acNode[S comparable] struct {
+	next     map[S]int32
+	fail     int32
+	dict     int32 // nearest node with patterns by fail links, -1 if none
+	patterns []int32
+}
+
+// Aho-Corasick automaton over sequences of symbols
+// (runes for texts, words for token sequences)
+type acAutomaton[S comparable] struct {
+	nodes   []acNode[S]
+	lengths []int
+}
+
+func newACAutomaton[S comparable]() *acAutomaton[S] {
+	return &acAutomaton[S]{
+		nodes: []acNode[S]{{next: map[S]int32{}, dict: -1}},
+	}
+}
+
+// Adds pattern with index `id`, empty patterns never match
+func (a *acAutomaton[S]) add(seq []S, id int) {
+	for len(a.lengths) <= id {
+		a.lengths = append(a.lengths, 0)
+	}
+	a.lengths[id] = len(seq)
+	if len(seq) == 0 {
+		return
+	}
+	state := int32(0)
+	for _, sym := range seq {
+		next, ok := a.nodes[state].next[sym]
+		if !ok {
+			next = int32(len(a.nodes))
+			a.nodes = append(a.nodes, acNode[S]{next: map[S]int32{}, dict: -1})
+			a.nodes[state].next[sym] = next
+		}
+		state = next
+	}
+	a.nodes[state].patterns = append(a.nodes[state].patterns, int32(id))
+}
+
+// Computes fail and dictionary links by breadth-first traversal
+func (a *acAutomaton[S]) build() {
+	queue := make([]int32, 0, len(a.nodes))
+	for _, child := range a.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for sym, child := range a.nodes[state].next {
+			fail := a.nodes[state].fail
+			for {
+				if next, ok := a.nodes[fail].next[sym]; ok {
+					a.nodes[child].fail = next
+					break
+				}
+				if fail == 0 {
+					break
+				}
+				fail = a.nodes[fail].fail
+			}
+			failNode := a.nodes[a.nodes[child].fail]
+			if len(failNode.patterns) > 0 {
+				a.nodes[child].dict = a.nodes[child].fail
+			} else {
+				a.nodes[child].dict = failNode.dict
+			}
+			queue = append(queue, child)
+		}
+	}
+}
+
+// Scans sequence and calls `emit` for every pattern occurrence
+// with index of symbol following the occurrence
+func (a *acAutomaton[S]) scan(seq []S, emit func(end int, pattern int32)) {
+	state := int32(0)
+	for i, sym := range seq {
+		for {
+			if next, ok := a.nodes[state].next[sym]; ok {
+				state = next
+				break
+			}
+			if state == 0 {
+				break
+			}
+			state = a.nodes[state].fail
+		}
+		for out := state; out > 0; out = a.nodes[out].dict {
+			for _, pattern := range a.nodes[out].patterns {
+				emit(i+1, pattern)
+			}
+		}
+	}
+}
+
+// Selects leftmost-longest non-overlapping matches
+func leftmostLongest(matches []ACMatch) []ACMatch {
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].From != matches[j].From {
+			return matches[i].From < matches[j].From
+		}
+		return matches[i].To > matches[j].To
+	})
+	selected := matches[:0]
+	end := 0
+	for _, match := range matches {
+		if match.From >= end {
+			selected = append(selected, match)
+			end = match.To
+		}
+	}
+	return selected
+}
+
+func foldRunes(runes []rune) []rune {
+	folded := make([]rune, len(runes))
+	for i, r := range runes {
+		folded[i] = foldRune(r)
+	}
+	return folded
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// RuneMatcher finds occurrences of many patterns in String object at once
+// (gazetteer lookup), time of matching doesn't depend on count of patterns
+type RuneMatcher struct {
+	automaton *acAutomaton[rune]
+	patterns  []ACPattern
+	options   ACOptions
+}
+
+// Constructor builds matcher for dictionary of patterns
+func NewRuneMatcher(patterns []ACPattern, options ACOptions) *RuneMatcher {
+	m := &RuneMatcher{
+		automaton: newACAutomaton[rune](),
+		patterns:  patterns,
+		options:   options,
+	}
+	for i, pattern := range patterns {
+		runes := []rune(pattern.Text)
+		if options.CaseInsensitive {
+			runes = foldRunes(runes)
+		}
+		m.automaton.add(runes, i)
+	}
+	m.automaton.build()
+	return m
+}
+
+// Finds all (possibly overlapping) occurrences of patterns in String object
+// Matches are ordered by end offset
+func (m *RuneMatcher) FindAll(s *String) []ACMatch {
+	runes := s.runes
+	if m.options.CaseInsensitive {
+		runes = foldRunes(runes)
+	}
+	var matches []ACMatch
+	m.automaton.scan(runes, func(end int, pattern int32) {
+		from := end - m.automaton.lengths[pattern]
+		if m.options.WholeWords {
+			if from > 0 && isWordRune(runes[from-1]) && isWordRune(runes[from]) {
+				return
+			}
+			if end < len(runes) && isWordRune(runes[end]) && isWordRune(runes[end-1]) {
+				return
+			}
+		}
+		matches = append(matches, ACMatch{from, end, int(pattern), m.patterns[pattern].Payload})
+	})
+	return matches
+}
+
+// Finds leftmost-longest non-overlapping occurrences of patterns in String object
+// Matches are ordered by offset
+func (m *RuneMatcher) FindLongest(s *String) []ACMatch {
+	return leftmostLongest(m.FindAll(s))
+}
+
+// WordMatcher finds occurrences of multi-word patterns in sequences of words
+// (e.g. words of tokens), patterns are split to words by white spaces
+type WordMatcher struct {
+	automaton *acAutomaton[string]
+	patterns  []ACPattern
+	options   ACOptions
+}
+
+func (m *WordMatcher) normalize(word string) string {
+	if m.options.CaseInsensitive {
+		return string(foldRunes([]rune(word)))
+	}
+	return word
+}
+
+// Constructor builds matcher for dictionary of patterns
+func NewWordMatcher(patterns []ACPattern, options ACOptions) *WordMatcher {
+	m := &WordMatcher{
+		automaton: newACAutomaton[string](),
+		patterns:  patterns,
+		options:   options,
+	}
+	for i, pattern := range patterns {
+		words := strings.Fields(pattern.Text)
+		for j, word := range words {
+			words[j] = m.normalize(word)
+		}
+		m.automaton.add(words, i)
+	}
+	m.automaton.build()
+	return m
+}
+
+// Finds all (possibly overlapping) occurrences of patterns in words sequence
+// Matches are ordered by end index
+func (m *WordMatcher) FindAll(words []string) []ACMatch {
+	if m.options.CaseInsensitive {
+		normalized := make([]string, len(words))
+		for i, word := range words {
+			normalized[i] = m.normalize(word)
+		}
+		words = normalized
+	}
+	var matches []ACMatch
+	m.automaton.scan(words, func(end int, pattern int32) {
+		from := end - m.automaton.lengths[pattern]
+		matches = append(matches, ACMatch{from, end, int(pattern), m.patterns[pattern].Payload})
+	})
+	return matches
+}
+
+// Finds leftmost-longest non-overlapping occurrences of patterns in words sequence
+func (m *WordMatcher) FindLongest(words []string) []ACMatch {
+	return leftmostLongest(m.FindAll(words))
+}
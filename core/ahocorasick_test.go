@@ -0,0 +1,72 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func BenchmarkRuneMatcher(b *testing.B) {
+	b.StopTimer()
+	patterns := make([]ACPattern, 10000)
+	for i := range patterns {
+		patterns[i] = ACPattern{Text: RandStringRunes(3 + i%10)}
+	}
+	matcher := NewRuneMatcher(patterns, ACOptions{CaseInsensitive: true})
+	str := NewString(RandStringRunes(100000))
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		_ = matcher.FindAll(str)
+	}
+}
+
+func TestRuneMatcher(t *testing.T) {
+	patterns := []ACPattern{
+		{"he", 0},
+		{"she", 1},
+		{"his", 2},
+		{"hers", 3},
+		{"", 4},
+	}
+	matcher := NewRuneMatcher(patterns, ACOptions{})
+	matches := matcher.FindAll(NewString("ushers"))
+	assert.Equal(t, matches, []ACMatch{{1, 4, 1, 1}, {2, 4, 0, 0}, {2, 6, 3, 3}})
+
+	matches = matcher.FindLongest(NewString("ushers"))
+	assert.Equal(t, matches, []ACMatch{{1, 4, 1, 1}})
+
+	assert.Nil(t, matcher.FindAll(NewString("HERS")))
+}
+
+func TestRuneMatcherOptions(t *testing.T) {
+	patterns := []ACPattern{
+		{"Київ", "Q1899"},
+		{"Київська Русь", "Q2426"},
+		{"Ки", "prefix"},
+	}
+	str := NewString("КИЇВСЬКА РУСЬ та київ, Кияни")
+
+	matcher := NewRuneMatcher(patterns, ACOptions{CaseInsensitive: true})
+	matches := matcher.FindLongest(str)
+	assert.Equal(t, matches, []ACMatch{{0, 13, 1, "Q2426"}, {17, 21, 0, "Q1899"}, {23, 25, 2, "prefix"}})
+
+	matcher = NewRuneMatcher(patterns, ACOptions{CaseInsensitive: true, WholeWords: true})
+	matches = matcher.FindAll(str)
+	assert.Equal(t, matches, []ACMatch{{0, 13, 1, "Q2426"}, {17, 21, 0, "Q1899"}})
+}
+
+func TestWordMatcher(t *testing.T) {
+	patterns := []ACPattern{
+		{"New York", "city"},
+		{"New  York Times", "newspaper"},
+		{"York", "city"},
+	}
+	words := []string{"the", "new", "york", "times", "in", "New", "York"}
+
+	matcher := NewWordMatcher(patterns, ACOptions{})
+	matches := matcher.FindAll(words)
+	assert.Equal(t, matches, []ACMatch{{5, 7, 0, "city"}, {6, 7, 2, "city"}})
+
+	matcher = NewWordMatcher(patterns, ACOptions{CaseInsensitive: true})
+	matches = matcher.FindLongest(words)
+	assert.Equal(t, matches, []ACMatch{{1, 4, 1, "newspaper"}, {5, 7, 0, "city"}})
+}
@@ -0,0 +1,306 @@
+package core
+
+import (
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// Single edit applied to StringBuilder: runes between `From` and `To`
+// (offsets in text as it was before the edit) were replaced by `Text`
+type Edit struct {
+	From int
+	To   int
+	Text string
+}
+
+// Part of edited text: either unchanged runes of original String
+// or replacement of original runes between `origFrom` and `origTo`
+type builderSegment struct {
+	origFrom int
+	origTo   int
+	runes    []rune
+	copied   bool
+}
+
+// StringBuilder applies inserts, deletes and replaces to String object
+// keeping track of every edit, so offsets in edited text can be mapped
+// to offsets in original one and vice versa:
+//
+//	b := NewStringBuilder(NewString("Call me at 555-1234, Bob"))
+//	b.Replace(11, 19, "<PHONE>")
+//	b.Insert(0, ">> ")
+//	align := b.Alignment()
+//	from, to := align.SpanToOriginal(14, 21)  // from == 11, to == 19
+//
+// Offsets of edits are always offsets in current (already edited) text
+type StringBuilder struct {
+	orig     *String
+	segments []builderSegment
+	length   int
+	edits    []Edit
+}
+
+// Constructor creates builder for editing of String object `orig`
+func NewStringBuilder(orig *String) *StringBuilder {
+	b := &StringBuilder{
+		orig:   orig,
+		length: orig.Length(),
+	}
+	if orig.Length() > 0 {
+		b.segments = []builderSegment{{0, orig.Length(), orig.runes, true}}
+	}
+	return b
+}
+
+// Returns length of edited text (count of runes)
+func (b *StringBuilder) Length() int {
+	return b.length
+}
+
+// Returns original String object
+func (b *StringBuilder) Original() *String {
+	return b.orig
+}
+
+// Returns all applied edits in order of applying
+func (b *StringBuilder) Edits() []Edit {
+	return b.edits
+}
+
+// Returns edited text as new String object
+func (b *StringBuilder) Result() *String {
+	runes := make([]rune, 0, b.length)
+	for _, seg := range b.segments {
+		runes = append(runes, seg.runes...)
+	}
+	return &String{runes: runes}
+}
+
+// Converts edited text to `string`
+func (b *StringBuilder) String() string {
+	return b.Result().String()
+}
+
+// Replaces runes between `from` and `to` of edited text by `text`
+// Returns `false` if location is out of text bounds
+func (b *StringBuilder) Replace(from, to int, text string) bool {
+	if from < 0 || from > to || to > b.length {
+		return false
+	}
+	b.replace(from, to, []rune(text))
+	b.edits = append(b.edits, Edit{from, to, text})
+	return true
+}
+
+// Inserts `text` at position `pos` of edited text
+func (b *StringBuilder) Insert(pos int, text string) bool {
+	return b.Replace(pos, pos, text)
+}
+
+// Deletes runes between `from` and `to` of edited text
+func (b *StringBuilder) Delete(from, to int) bool {
+	return b.Replace(from, to, "")
+}
+
+// Replaces all matches of regexp in edited text by `template`
+// where $1, ${name} etc. are expanded to capture groups.
+// Returns count of replaced matches (matches equal to their replacement
+// are left as is and aren't counted)
+func (b *StringBuilder) ReplaceRegexp(re *regexp.Regexp, template string) int {
+	text := b.String()
+	locs := re.FindAllStringSubmatchIndex(text, -1)
+	count := 0
+	// replace from the end, so offsets of other matches stay valid
+	for i := len(locs) - 1; i >= 0; i-- {
+		loc := locs[i]
+		// template is expanded against the whole text, so submatches
+		// are the same as found in context
+		repl := string(re.ExpandString(nil, template, text, loc))
+		if repl == text[loc[0]:loc[1]] {
+			continue
+		}
+		from := utf8.RuneCountInString(text[:loc[0]])
+		to := from + utf8.RuneCountInString(text[loc[0]:loc[1]])
+		b.Replace(from, to, repl)
+		count++
+	}
+	return count
+}
+
+func (b *StringBuilder) replace(from, to int, text []rune) {
+	segments := make([]builderSegment, 0, len(b.segments)+2)
+	replacement := builderSegment{}
+	var prefix, suffix []rune
+	var absorbed int
+	inserted := false
+
+	insert := func() {
+		if inserted {
+			return
+		}
+		inserted = true
+		if len(segments) > 0 {
+			replacement.origFrom = segments[len(segments)-1].origTo
+		}
+		replacement.origTo = replacement.origFrom + absorbed
+		replacement.runes = append(append(prefix, text...), suffix...)
+		if len(replacement.runes) > 0 || absorbed > 0 {
+			segments = append(segments, replacement)
+		}
+	}
+
+	pos := 0
+	for _, seg := range b.segments {
+		start, end := pos, pos+len(seg.runes)
+		pos = end
+
+		switch {
+		case end <= from:
+			segments = append(segments, seg)
+			continue
+		case start >= to:
+			insert()
+			segments = append(segments, seg)
+			continue
+		}
+
+		// segment overlaps edited location
+		cutFrom, cutTo := 0, len(seg.runes)
+		if from > start {
+			cutFrom = from - start
+		}
+		if to < end {
+			cutTo = to - start
+		}
+		if seg.copied {
+			if cutFrom > 0 {
+				segments = append(segments, builderSegment{seg.origFrom, seg.origFrom + cutFrom, seg.runes[:cutFrom], true})
+			}
+			absorbed += cutTo - cutFrom
+			if cutTo < len(seg.runes) {
+				insert()
+				segments = append(segments, builderSegment{seg.origFrom + cutTo, seg.origTo, seg.runes[cutTo:], true})
+			}
+			continue
+		}
+		// replaced segments can't be split, they are merged with new replacement
+		prefix = append(prefix, seg.runes[:cutFrom]...)
+		suffix = append(suffix, seg.runes[cutTo:]...)
+		absorbed += seg.origTo - seg.origFrom
+	}
+	insert()
+
+	b.segments = segments
+	b.length += len(text) - (to - from)
+}
+
+// Returns alignment between original and edited text
+func (b *StringBuilder) Alignment() *Alignment {
+	align := &Alignment{
+		spans:   make([]alignSpan, 0, len(b.segments)),
+		lengths: [2]int{b.orig.Length(), b.length},
+	}
+	pos := 0
+	for _, seg := range b.segments {
+		align.spans = append(align.spans, alignSpan{
+			coords: [2][2]int{{seg.origFrom, seg.origTo}, {pos, pos + len(seg.runes)}},
+			copied: seg.copied,
+		})
+		pos += len(seg.runes)
+	}
+	return align
+}
+
+const (
+	alignOrig = 0
+	alignEdit = 1
+)
+
+// Aligned parts of original and edited texts
+type alignSpan struct {
+	coords [2][2]int // {from, to} in original and edited text
+	copied bool
+}
+
+// Alignment maps offsets between original and edited text.
+// Offsets inside replaced parts are mapped to the bounds of replacement:
+// start of span is mapped to the start of replacement, end of span - to its end
+type Alignment struct {
+	spans   []alignSpan
+	lengths [2]int
+}
+
+// Maps position `pos` as the start of span from `src` text to `dst` one
+func (a *Alignment) mapStart(pos, src, dst int) int {
+	if pos < 0 || pos > a.lengths[src] {
+		return -1
+	}
+	i := sort.Search(len(a.spans), func(i int) bool {
+		return a.spans[i].coords[src][1] > pos
+	})
+	if i == len(a.spans) {
+		return a.lengths[dst]
+	}
+	span := a.spans[i]
+	if span.copied {
+		return span.coords[dst][0] + pos - span.coords[src][0]
+	}
+	return span.coords[dst][0]
+}
+
+// Maps position `pos` as the end of span from `src` text to `dst` one
+func (a *Alignment) mapEnd(pos, src, dst int) int {
+	if pos < 0 || pos > a.lengths[src] {
+		return -1
+	}
+	i := sort.Search(len(a.spans), func(i int) bool {
+		return a.spans[i].coords[src][0] >= pos
+	}) - 1
+	if i < 0 {
+		return 0
+	}
+	span := a.spans[i]
+	if span.copied {
+		return span.coords[dst][0] + pos - span.coords[src][0]
+	}
+	return span.coords[dst][1]
+}
+
+func (a *Alignment) mapSpan(from, to, src, dst int) (int, int) {
+	if from > to {
+		return -1, -1
+	}
+	dstFrom, dstTo := a.mapStart(from, src, dst), a.mapEnd(to, src, dst)
+	if dstFrom < 0 || dstTo < 0 {
+		return -1, -1
+	}
+	if dstTo < dstFrom {
+		dstTo = dstFrom
+	}
+	return dstFrom, dstTo
+}
+
+// Maps offset in edited text to offset in original one
+// Returns -1 if offset is out of bounds
+func (a *Alignment) ToOriginal(pos int) int {
+	return a.mapStart(pos, alignEdit, alignOrig)
+}
+
+// Maps offset in original text to offset in edited one
+// Returns -1 if offset is out of bounds
+func (a *Alignment) FromOriginal(pos int) int {
+	return a.mapStart(pos, alignOrig, alignEdit)
+}
+
+// Maps location {from, to} in edited text to location in original one
+// (e.g. to project tokens found in edited text back to original).
+// Location that covers part of replacement is extended to the whole replaced text
+func (a *Alignment) SpanToOriginal(from, to int) (int, int) {
+	return a.mapSpan(from, to, alignEdit, alignOrig)
+}
+
+// Maps location {from, to} in original text to location in edited one
+func (a *Alignment) SpanFromOriginal(from, to int) (int, int) {
+	return a.mapSpan(from, to, alignOrig, alignEdit)
+}
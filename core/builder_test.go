@@ -0,0 +1,92 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+)
+
+func TestStringBuilderEdits(t *testing.T) {
+	b := NewStringBuilder(NewString("Call me at 555-1234, Bob"))
+	assert.True(t, b.Replace(11, 19, "<PHONE>"))
+	assert.True(t, b.Insert(0, ">> "))
+	assert.True(t, b.Delete(24, 26))
+	assert.False(t, b.Delete(20, 40))
+	assert.False(t, b.Insert(-1, "x"))
+
+	assert.Equal(t, b.String(), ">> Call me at <PHONE>, B")
+	assert.Equal(t, b.Length(), 24)
+	assert.Equal(t, b.Original().String(), "Call me at 555-1234, Bob")
+	assert.Equal(t, b.Edits(), []Edit{{11, 19, "<PHONE>"}, {0, 0, ">> "}, {24, 26, ""}})
+
+	align := b.Alignment()
+	from, to := align.SpanToOriginal(14, 21)
+	assert.Equal(t, []int{from, to}, []int{11, 19})
+	from, to = align.SpanToOriginal(16, 18) // inside replacement
+	assert.Equal(t, []int{from, to}, []int{11, 19})
+	from, to = align.SpanToOriginal(3, 7)
+	assert.Equal(t, []int{from, to}, []int{0, 4})
+	from, to = align.SpanToOriginal(0, 2) // inserted text
+	assert.Equal(t, []int{from, to}, []int{0, 0})
+	from, to = align.SpanToOriginal(21, 24)
+	assert.Equal(t, []int{from, to}, []int{19, 22})
+
+	from, to = align.SpanFromOriginal(0, 4)
+	assert.Equal(t, []int{from, to}, []int{3, 7})
+	from, to = align.SpanFromOriginal(15, 19)
+	assert.Equal(t, []int{from, to}, []int{14, 21})
+	from, to = align.SpanFromOriginal(21, 24) // partially deleted
+	assert.Equal(t, []int{from, to}, []int{23, 24})
+
+	assert.Equal(t, align.ToOriginal(3), 0)
+	assert.Equal(t, align.ToOriginal(24), 24)
+	assert.Equal(t, align.FromOriginal(20), 22)
+	assert.Equal(t, align.ToOriginal(25), -1)
+	from, to = align.SpanToOriginal(5, 4)
+	assert.Equal(t, []int{from, to}, []int{-1, -1})
+}
+
+func TestStringBuilderOverlappingEdits(t *testing.T) {
+	b := NewStringBuilder(NewString("ёжик в тумане"))
+	b.Replace(0, 4, "ЁЖИК")
+	b.Replace(2, 6, "")   // cuts replaced and copied parts
+	b.Insert(2, "ж")      // inserts into merged replacement
+	b.Replace(6, 10, "!") // to the end
+	assert.Equal(t, b.String(), "ЁЖж ту!")
+
+	align := b.Alignment()
+	from, to := align.SpanToOriginal(0, 3)
+	assert.Equal(t, []int{from, to}, []int{0, 6})
+	from, to = align.SpanToOriginal(3, 5)
+	assert.Equal(t, []int{from, to}, []int{6, 8})
+	from, to = align.SpanFromOriginal(7, 13)
+	assert.Equal(t, []int{from, to}, []int{4, 7})
+}
+
+func TestStringBuilderRegexp(t *testing.T) {
+	b := NewStringBuilder(NewString("Иван: +380-44-123, Петро: +380-67-555"))
+	cnt := b.ReplaceRegexp(regexp.MustCompile(`\+(\d+)-\d+-\d+`), "+$1-XX-XXX")
+	assert.Equal(t, cnt, 2)
+	assert.Equal(t, b.String(), "Иван: +380-XX-XXX, Петро: +380-XX-XXX")
+
+	// tokens found in edited text are projected back to the original one
+	edited := b.Result()
+	loc := edited.FindAll(regexp.MustCompile(`\+\S+`))[1]
+	from, to := b.Alignment().SpanToOriginal(loc[0], loc[1])
+	assert.Equal(t, b.Original().Substring(from, to).String(), "+380-67-555")
+
+	// matches depend on context of the whole text
+	ctx := NewStringBuilder(NewString("afoo bar foo"))
+	assert.Equal(t, ctx.ReplaceRegexp(regexp.MustCompile(`\Bfoo`), "[$0]"), 1)
+	assert.Equal(t, ctx.String(), "a[foo] bar foo")
+	ctx = NewStringBuilder(NewString("ab ab"))
+	assert.Equal(t, ctx.ReplaceRegexp(regexp.MustCompile(`^(a)b`), "${1}c"), 1)
+	assert.Equal(t, ctx.String(), "ac ab")
+	// unchanged matches aren't counted
+	assert.Equal(t, ctx.ReplaceRegexp(regexp.MustCompile(`a(b|c)`), "a$1"), 0)
+
+	empty := NewStringBuilder(NewString(""))
+	empty.Insert(0, "abc")
+	assert.Equal(t, empty.String(), "abc")
+	assert.Equal(t, empty.Alignment().ToOriginal(2), 0)
+}
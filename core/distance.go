@@ -0,0 +1,263 @@
+package core
+
+import "math"
+
+// Costs of edit operations for WeightedDistance
+type EditCosts struct {
+	Insert     float64
+	Delete     float64
+	Substitute float64
+	// Cost of transposition of two adjacent runes,
+	// transpositions aren't allowed if it's zero
+	Transpose float64
+	// Optional cost of substitution of rune `a` by rune `b`
+	// (e.g. keyboard or phonetic distance), overrides Substitute
+	SubstituteFn func(a, b rune) float64
+}
+
+// Unit costs of insertion, deletion and substitution
+var LevenshteinCosts = EditCosts{Insert: 1, Delete: 1, Substitute: 1}
+
+// Unit costs of insertion, deletion, substitution and transposition
+var DamerauCosts = EditCosts{Insert: 1, Delete: 1, Substitute: 1, Transpose: 1}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Levenshtein returns minimal count of rune insertions,
+// deletions and substitutions that transform `a` to `b`
+func Levenshtein(a, b *String) int {
+	ra, rb := a.runes, b.runes
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// LevenshteinBounded computes Levenshtein distance if it doesn't exceed `max`.
+// Returns distance and `true`, or `max+1` and `false` as soon as it's clear
+// that distance is greater than `max`. Works in O(max * length) time,
+// so it's suitable for fast filtering of candidates
+func LevenshteinBounded(a, b *String, max int) (int, bool) {
+	ra, rb := a.runes, b.runes
+	if max < 0 {
+		return max + 1, false
+	}
+	// distance never exceeds length of the longer string, larger bound
+	// would only overflow `max+1` and `i+max`
+	max = minInt(max, maxInt(len(ra), len(rb)))
+	if len(ra)-len(rb) > max || len(rb)-len(ra) > max {
+		return max + 1, false
+	}
+	over := max + 1
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = minInt(j, over)
+	}
+	for i := 1; i <= len(ra); i++ {
+		// only cells of diagonal band |i-j| <= max can be within bound
+		from, to := maxInt(1, i-max), minInt(len(rb), i+max)
+		rowMin := over
+		if from == 1 {
+			cur[0] = minInt(i, over)
+			rowMin = cur[0]
+		} else {
+			cur[from-1] = over
+		}
+		for j := from; j <= to; j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d := minInt(prev[j-1]+cost, cur[j-1]+1)
+			if j < i+max {
+				d = minInt(d, prev[j]+1)
+			}
+			cur[j] = minInt(d, over)
+			rowMin = minInt(rowMin, cur[j])
+		}
+		if to < len(rb) {
+			cur[to+1] = over
+		}
+		if rowMin > max {
+			return over, false
+		}
+		prev, cur = cur, prev
+	}
+	if prev[len(rb)] > max {
+		return over, false
+	}
+	return prev[len(rb)], true
+}
+
+// DamerauLevenshtein returns optimal string alignment distance: like Levenshtein
+// but transposition of two adjacent runes counts as one edit
+// (no substring is edited more than once)
+func DamerauLevenshtein(a, b *String) int {
+	return int(WeightedDistance(a, b, DamerauCosts))
+}
+
+// WeightedDistance returns minimal cost of edits that transform `a` to `b`.
+// Transpositions are taken into account (as in optimal string alignment)
+// if costs.Transpose is not zero
+func WeightedDistance(a, b *String, costs EditCosts) float64 {
+	ra, rb := a.runes, b.runes
+	rows := [3][]float64{
+		make([]float64, len(rb)+1),
+		make([]float64, len(rb)+1),
+		make([]float64, len(rb)+1),
+	}
+	subCost := func(x, y rune) float64 {
+		if x == y {
+			return 0
+		}
+		if costs.SubstituteFn != nil {
+			return costs.SubstituteFn(x, y)
+		}
+		return costs.Substitute
+	}
+
+	// rows[0] is previous of previous row, rows[1] - previous, rows[2] - current
+	for j := range rows[1] {
+		rows[1][j] = float64(j) * costs.Insert
+	}
+	for i := 1; i <= len(ra); i++ {
+		prev2, prev, cur := rows[0], rows[1], rows[2]
+		cur[0] = float64(i) * costs.Delete
+		for j := 1; j <= len(rb); j++ {
+			d := math.Min(prev[j]+costs.Delete, cur[j-1]+costs.Insert)
+			d = math.Min(d, prev[j-1]+subCost(ra[i-1], rb[j-1]))
+			if costs.Transpose != 0 && i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d = math.Min(d, prev2[j-2]+costs.Transpose)
+			}
+			cur[j] = d
+		}
+		rows[0], rows[1], rows[2] = prev, cur, prev2
+	}
+	return rows[1][len(rb)]
+}
+
+// LevenshteinSimilarity returns 1 - distance / max(length) in [0, 1] range
+func LevenshteinSimilarity(a, b *String) float64 {
+	maxLen := maxInt(a.Length(), b.Length())
+	if maxLen == 0 {
+		return 1
+	}
+	return 1 - float64(Levenshtein(a, b))/float64(maxLen)
+}
+
+// Jaro returns Jaro similarity of strings in [0, 1] range
+func Jaro(a, b *String) float64 {
+	ra, rb := a.runes, b.runes
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+	window := maxInt(len(ra), len(rb))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+	matchedA := make([]bool, len(ra))
+	matchedB := make([]bool, len(rb))
+
+	matches := 0
+	for i, r := range ra {
+		from, to := maxInt(0, i-window), minInt(len(rb)-1, i+window)
+		for j := from; j <= to; j++ {
+			if !matchedB[j] && rb[j] == r {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions := 0
+	j := 0
+	for i, r := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if r != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+	m := float64(matches)
+	return (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions/2))/m) / 3
+}
+
+// JaroWinkler returns Jaro-Winkler similarity of strings in [0, 1] range:
+// Jaro similarity boosted for strings with common prefix (up to 4 runes)
+func JaroWinkler(a, b *String) float64 {
+	const prefixScale = 0.1
+	const maxPrefix = 4
+
+	sim := Jaro(a, b)
+	prefix := 0
+	for prefix < minInt(maxPrefix, minInt(a.Length(), b.Length())) && a.runes[prefix] == b.runes[prefix] {
+		prefix++
+	}
+	return sim + float64(prefix)*prefixScale*(1-sim)
+}
+
+// LongestCommonSubsequence returns length of the longest common
+// (not necessarily contiguous) subsequence of runes
+func LongestCommonSubsequence(a, b *String) int {
+	ra, rb := a.runes, b.runes
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			if ra[i-1] == rb[j-1] {
+				cur[j] = prev[j-1] + 1
+			} else {
+				cur[j] = maxInt(prev[j], cur[j-1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// LCSSimilarity returns 2 * LCS / (length(a) + length(b)) in [0, 1] range
+func LCSSimilarity(a, b *String) float64 {
+	total := a.Length() + b.Length()
+	if total == 0 {
+		return 1
+	}
+	return 2 * float64(LongestCommonSubsequence(a, b)) / float64(total)
+}
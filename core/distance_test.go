@@ -0,0 +1,108 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func BenchmarkLevenshtein(b *testing.B) {
+	b.StopTimer()
+	s1, s2 := NewString(RandStringRunes(100)), NewString(RandStringRunes(100))
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		_ = Levenshtein(s1, s2)
+	}
+}
+
+func BenchmarkLevenshteinBounded(b *testing.B) {
+	b.StopTimer()
+	s1, s2 := NewString(RandStringRunes(100)), NewString(RandStringRunes(100))
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = LevenshteinBounded(s1, s2, 3)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		lev, osa int
+	}{
+		{"kitten", "sitting", 3, 3},
+		{"", "ёжик", 4, 4},
+		{"ёжик", "ёжик", 0, 0},
+		{"ёжик", "жёик", 2, 1},
+		{"ca", "abc", 3, 3},
+		{"こんにちは", "こにんちわ", 3, 2},
+	}
+	for _, c := range cases {
+		a, b := NewString(c.a), NewString(c.b)
+		assert.Equal(t, Levenshtein(a, b), c.lev, c.a+" "+c.b)
+		assert.Equal(t, Levenshtein(b, a), c.lev, c.a+" "+c.b)
+		assert.Equal(t, DamerauLevenshtein(a, b), c.osa, c.a+" "+c.b)
+		assert.Equal(t, int(WeightedDistance(a, b, LevenshteinCosts)), c.lev, c.a+" "+c.b)
+	}
+
+	a, b := NewString("Kyiv"), NewString("kiev")
+	costs := EditCosts{Insert: 1, Delete: 1, Substitute: 2, SubstituteFn: func(x, y rune) float64 {
+		if x|0x20 == y|0x20 {
+			return 0.1 // case difference only
+		}
+		return 2
+	}}
+	assert.InDelta(t, WeightedDistance(a, b, costs), 2.1, 1e-9)
+	assert.InDelta(t, LevenshteinSimilarity(a, b), 0.25, 1e-9)
+	assert.InDelta(t, LevenshteinSimilarity(NewString(""), NewString("")), 1, 1e-9)
+}
+
+func TestLevenshteinBounded(t *testing.T) {
+	d, ok := LevenshteinBounded(NewString("kitten"), NewString("sitting"), 3)
+	assert.True(t, ok)
+	assert.Equal(t, d, 3)
+	d, ok = LevenshteinBounded(NewString("kitten"), NewString("sitting"), 2)
+	assert.False(t, ok)
+	assert.Equal(t, d, 3)
+	_, ok = LevenshteinBounded(NewString("a"), NewString("abcd"), 2)
+	assert.False(t, ok)
+	d, ok = LevenshteinBounded(NewString("a"), NewString("a"), -2)
+	assert.False(t, ok)
+	assert.Equal(t, d, -1)
+	d, ok = LevenshteinBounded(NewString("kitten"), NewString("sitting"), math.MaxInt)
+	assert.True(t, ok)
+	assert.Equal(t, d, 3)
+	d, ok = LevenshteinBounded(NewString(""), NewString("abc"), math.MaxInt)
+	assert.True(t, ok)
+	assert.Equal(t, d, 3)
+
+	for i := 0; i < 500; i++ {
+		a := NewString(RandStringRunes(rand.Intn(8)))
+		b := NewString(RandStringRunes(rand.Intn(8)))
+		max := rand.Intn(6)
+		exact := Levenshtein(a, b)
+		d, ok := LevenshteinBounded(a, b, max)
+		if exact <= max {
+			assert.True(t, ok)
+			assert.Equal(t, d, exact, a.String()+" "+b.String())
+		} else {
+			assert.False(t, ok, a.String()+" "+b.String())
+		}
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	assert.InDelta(t, Jaro(NewString("MARTHA"), NewString("MARHTA")), 0.944, 1e-3)
+	assert.InDelta(t, JaroWinkler(NewString("MARTHA"), NewString("MARHTA")), 0.961, 1e-3)
+	assert.InDelta(t, JaroWinkler(NewString("DIXON"), NewString("DICKSONX")), 0.813, 1e-3)
+	assert.InDelta(t, JaroWinkler(NewString("Олександр"), NewString("Олександра")), 0.98, 1e-3)
+	assert.InDelta(t, JaroWinkler(NewString("abc"), NewString("xyz")), 0, 1e-9)
+	assert.InDelta(t, JaroWinkler(NewString(""), NewString("")), 1, 1e-9)
+}
+
+func TestLCS(t *testing.T) {
+	assert.Equal(t, LongestCommonSubsequence(NewString("ABCBDAB"), NewString("BDCABA")), 4)
+	assert.Equal(t, LongestCommonSubsequence(NewString("ёжик"), NewString("ёлка")), 2)
+	assert.InDelta(t, LCSSimilarity(NewString("ёжик"), NewString("ёлка")), 0.5, 1e-9)
+	assert.InDelta(t, LCSSimilarity(NewString(""), NewString("")), 1, 1e-9)
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"unicode"
+)
+
+// Alignment of String objects produced by CaseFold, RemoveDiacritics
+// and Transliterate is an array where i-th item is the position
+// of original rune that produced i-th rune of the new String object
+
+// Returns simple case folding of rune (statuses C and S in CaseFolding.txt)
+func foldRune(r rune) rune {
+	switch {
+	case r == 0x0131: // dotless i has no folding (status T only)
+		return r
+	case r >= 0x13f8 && r <= 0x13fd: // Cherokee folds to upper case
+		return r - 8
+	case r >= 0xab70 && r <= 0xabbf:
+		return r - 0xab70 + 0x13a0
+	case r >= 0x13a0 && r <= 0x13f5:
+		return r
+	}
+	return unicode.ToLower(unicode.ToUpper(r))
+}
+
+// CaseFold returns full Unicode case folding of String object
+// (e.g. "Straße" and "STRASSE" are both folded to "strasse")
+// and alignment of new String object to the original one
+func (s *String) CaseFold() (*String, []int) {
+	runes := make([]rune, 0, len(s.runes))
+	align := make([]int, 0, len(s.runes))
+
+	for i, r := range s.runes {
+		if folded, ok := fullCaseFolding[r]; ok {
+			for _, f := range folded {
+				runes = append(runes, f)
+				align = append(align, i)
+			}
+			continue
+		}
+		runes = append(runes, foldRune(r))
+		align = append(align, i)
+	}
+	return &String{runes: runes}, align
+}
+
+// Appends rune `r` without diacritic marks to `dst`
+func appendStripped(dst []rune, r rune) []rune {
+	if base, ok := diacriticsExceptions[r]; ok {
+		return append(dst, base)
+	}
+	if r < 0x80 {
+		return append(dst, r)
+	}
+	for _, d := range norm.NFD.String(string(r)) {
+		if !unicode.Is(unicode.Mn, d) {
+			dst = append(dst, d)
+		}
+	}
+	return dst
+}
+
+// RemoveDiacritics returns String object with all diacritic marks removed
+// ("Crème brûlée" becomes "Creme brulee", "ёлка" becomes "елка")
+// and alignment of new String object to the original one
+func (s *String) RemoveDiacritics() (*String, []int) {
+	runes := make([]rune, 0, len(s.runes))
+	align := make([]int, 0, len(s.runes))
+
+	for i, r := range s.runes {
+		n := len(runes)
+		runes = appendStripped(runes, r)
+		for ; n < len(runes); n++ {
+			align = append(align, i)
+		}
+	}
+	return &String{runes: runes}, align
+}
+
+// ComposeAlignments combines alignments of two sequential transformations:
+// `first` aligns String B to String A, `second` aligns String C to String B.
+// Returns alignment of String C to String A
+func ComposeAlignments(first, second []int) []int {
+	align := make([]int, len(second))
+	for i, pos := range second {
+		align[i] = first[pos]
+	}
+	return align
+}
@@ -0,0 +1,90 @@
+package core
+
+// Full case foldings (status F in CaseFolding.txt): characters
+// that fold to more than one rune. Other characters are folded
+// by simple case mapping in `foldRune`
+var fullCaseFolding = map[rune][]rune{
+	0x00df: {0x0073, 0x0073},
+	0x0130: {0x0069, 0x0307},
+	0x0149: {0x02bc, 0x006e},
+	0x01f0: {0x006a, 0x030c},
+	0x0390: {0x03b9, 0x0308, 0x0301},
+	0x03b0: {0x03c5, 0x0308, 0x0301},
+	0x0587: {0x0565, 0x0582},
+	0x1e96: {0x0068, 0x0331},
+	0x1e97: {0x0074, 0x0308},
+	0x1e98: {0x0077, 0x030a},
+	0x1e99: {0x0079, 0x030a},
+	0x1e9a: {0x0061, 0x02be},
+	0x1e9e: {0x0073, 0x0073},
+	0x1f50: {0x03c5, 0x0313},
+	0x1f52: {0x03c5, 0x0313, 0x0300},
+	0x1f54: {0x03c5, 0x0313, 0x0301},
+	0x1f56: {0x03c5, 0x0313, 0x0342},
+	0x1fb2: {0x1f70, 0x03b9},
+	0x1fb3: {0x03b1, 0x03b9},
+	0x1fb4: {0x03ac, 0x03b9},
+	0x1fb6: {0x03b1, 0x0342},
+	0x1fb7: {0x03b1, 0x0342, 0x03b9},
+	0x1fbc: {0x03b1, 0x03b9},
+	0x1fc2: {0x1f74, 0x03b9},
+	0x1fc3: {0x03b7, 0x03b9},
+	0x1fc4: {0x03ae, 0x03b9},
+	0x1fc6: {0x03b7, 0x0342},
+	0x1fc7: {0x03b7, 0x0342, 0x03b9},
+	0x1fcc: {0x03b7, 0x03b9},
+	0x1fd2: {0x03b9, 0x0308, 0x0300},
+	0x1fd3: {0x03b9, 0x0308, 0x0301},
+	0x1fd6: {0x03b9, 0x0342},
+	0x1fd7: {0x03b9, 0x0308, 0x0342},
+	0x1fe2: {0x03c5, 0x0308, 0x0300},
+	0x1fe3: {0x03c5, 0x0308, 0x0301},
+	0x1fe4: {0x03c1, 0x0313},
+	0x1fe6: {0x03c5, 0x0342},
+	0x1fe7: {0x03c5, 0x0308, 0x0342},
+	0x1ff2: {0x1f7c, 0x03b9},
+	0x1ff3: {0x03c9, 0x03b9},
+	0x1ff4: {0x03ce, 0x03b9},
+	0x1ff6: {0x03c9, 0x0342},
+	0x1ff7: {0x03c9, 0x0342, 0x03b9},
+	0x1ffc: {0x03c9, 0x03b9},
+	0xfb00: {0x0066, 0x0066},
+	0xfb01: {0x0066, 0x0069},
+	0xfb02: {0x0066, 0x006c},
+	0xfb03: {0x0066, 0x0066, 0x0069},
+	0xfb04: {0x0066, 0x0066, 0x006c},
+	0xfb05: {0x0073, 0x0074},
+	0xfb06: {0x0073, 0x0074},
+	0xfb13: {0x0574, 0x0576},
+	0xfb14: {0x0574, 0x0565},
+	0xfb15: {0x0574, 0x056b},
+	0xfb16: {0x057e, 0x0576},
+	0xfb17: {0x0574, 0x056d},
+}
+
+func init() {
+	// Greek letters with ypogegrammeni and prosgegrammeni:
+	// U+1F80..U+1FAF fold to letter with psili/dasia followed by iota
+	bases := []rune{0x1f00, 0x1f20, 0x1f60}
+	for i, base := range bases {
+		for j := rune(0); j < 8; j++ {
+			fullCaseFolding[0x1f80+rune(i)*0x10+j] = []rune{base + j, 0x03b9}
+			fullCaseFolding[0x1f88+rune(i)*0x10+j] = []rune{base + j, 0x03b9}
+		}
+	}
+}
+
+// Letters with diacritics that have no canonical decomposition
+var diacriticsExceptions = map[rune]rune{
+	'ø': 'o', 'Ø': 'O',
+	'ł': 'l', 'Ł': 'L',
+	'đ': 'd', 'Đ': 'D',
+	'ħ': 'h', 'Ħ': 'H',
+	'ŧ': 't', 'Ŧ': 'T',
+	'ƀ': 'b', 'Ƀ': 'B',
+	'ɨ': 'i', 'Ɨ': 'I',
+	'ƶ': 'z', 'Ƶ': 'Z',
+	'ı': 'i',
+	'ȷ': 'j',
+	'ɍ': 'r', 'Ɍ': 'R',
+}
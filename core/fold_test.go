@@ -0,0 +1,45 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCaseFold(t *testing.T) {
+	folded, align := NewString("Straße ΣΊΣΥΦΟΣ").CaseFold()
+	assert.Equal(t, folded.String(), "strasse σίσυφοσ")
+	assert.Equal(t, align, []int{0, 1, 2, 3, 4, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13})
+
+	folded, _ = NewString("STRASSE σίσυφος").CaseFold()
+	assert.Equal(t, folded.String(), "strasse σίσυφοσ")
+
+	folded, align = NewString("ﬁЁЛКА").CaseFold()
+	assert.Equal(t, folded.String(), "fiёлка")
+	assert.Equal(t, align, []int{0, 0, 1, 2, 3, 4})
+
+	folded, _ = NewString("İı Kelvin").CaseFold()
+	assert.Equal(t, folded.String(), "i̇ı kelvin")
+
+	folded, align = NewString("").CaseFold()
+	assert.Equal(t, folded.Length(), 0)
+	assert.Equal(t, len(align), 0)
+}
+
+func TestRemoveDiacritics(t *testing.T) {
+	stripped, align := NewString("Crème brûlée").RemoveDiacritics()
+	assert.Equal(t, stripped.String(), "Creme brulee")
+	assert.Equal(t, len(align), 12)
+
+	// combining marks are removed with alignment kept to base letters
+	stripped, align = NewString("éёлка Łódź").RemoveDiacritics()
+	assert.Equal(t, stripped.String(), "eелка Lodz")
+	assert.Equal(t, align, []int{0, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+}
+
+func TestComposeAlignments(t *testing.T) {
+	str := NewString("ßÉ")
+	folded, first := str.CaseFold()
+	stripped, second := folded.RemoveDiacritics()
+	assert.Equal(t, stripped.String(), "sse")
+	assert.Equal(t, ComposeAlignments(first, second), []int{0, 0, 1})
+}
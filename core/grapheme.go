@@ -0,0 +1,304 @@
+package core
+
+import "unicode"
+
+// Grapheme_Cluster_Break property values (UAX #29)
+type graphemeBreakProp uint8
+
+const (
+	gbOther graphemeBreakProp = iota
+	gbCR
+	gbLF
+	gbControl
+	gbExtend
+	gbZWJ
+	gbRegionalIndicator
+	gbPrepend
+	gbSpacingMark
+	gbL
+	gbV
+	gbT
+	gbLV
+	gbLVT
+)
+
+// Indic_Conjunct_Break property values
+type indicConjunctProp uint8
+
+const (
+	incbNone indicConjunctProp = iota
+	incbConsonant
+	incbLinker
+	incbExtend
+)
+
+const (
+	hangulSBase  = 0xac00
+	hangulSCount = 11172
+	hangulTCount = 28
+)
+
+func isGraphemeExtend(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Other_Grapheme_Extend) ||
+		(r >= 0x1f3fb && r <= 0x1f3ff) // emoji modifiers
+}
+
+func graphemeBreakProperty(r rune) graphemeBreakProp {
+	switch {
+	case r == '\r':
+		return gbCR
+	case r == '\n':
+		return gbLF
+	case r == 0x200d:
+		return gbZWJ
+	case r >= 0x1f1e6 && r <= 0x1f1ff:
+		return gbRegionalIndicator
+	case r >= 0x1100 && r <= 0x115f, r >= 0xa960 && r <= 0xa97c:
+		return gbL
+	case r >= 0x1160 && r <= 0x11a7, r >= 0xd7b0 && r <= 0xd7c6:
+		return gbV
+	case r >= 0x11a8 && r <= 0x11ff, r >= 0xd7cb && r <= 0xd7fb:
+		return gbT
+	case r >= hangulSBase && r < hangulSBase+hangulSCount:
+		if (r-hangulSBase)%hangulTCount == 0 {
+			return gbLV
+		}
+		return gbLVT
+	case unicode.In(r, unicode.Prepended_Concatenation_Mark, graphemePrepend):
+		return gbPrepend
+	case isGraphemeExtend(r):
+		return gbExtend
+	case unicode.In(r, unicode.Cc, unicode.Cf, unicode.Cs, unicode.Zl, unicode.Zp):
+		return gbControl
+	case unicode.Is(unicode.Mc, r), r == 0x0e33, r == 0x0eb3:
+		return gbSpacingMark
+	}
+	return gbOther
+}
+
+func indicConjunctProperty(r rune) indicConjunctProp {
+	switch {
+	case unicode.Is(indicConjunctConsonant, r):
+		return incbConsonant
+	case unicode.Is(indicConjunctLinker, r):
+		return incbLinker
+	case r == 0x200d || isGraphemeExtend(r):
+		return incbExtend
+	}
+	return incbNone
+}
+
+// Returns position of the grapheme cluster boundary that follows
+// position `from` in runes (the end of cluster started at `from`).
+// Implements extended grapheme clusters rules of UAX #29
+func graphemeClusterEnd(runes []rune, from int) int {
+	if from >= len(runes) {
+		return len(runes)
+	}
+
+	prev := graphemeBreakProperty(runes[from])
+
+	// GB11: 0 - none, 1 - after ExtPict Extend*, 2 - after ExtPict Extend* ZWJ
+	emoji := 0
+	if unicode.Is(extendedPictographic, runes[from]) {
+		emoji = 1
+	}
+	// GB12, GB13: count of sequential regional indicators
+	riCount := 0
+	if prev == gbRegionalIndicator {
+		riCount = 1
+	}
+	// GB9c: 0 - none, 1 - after Consonant [Extend Linker]*, 2 - linker was seen
+	conjunct := 0
+	if indicConjunctProperty(runes[from]) == incbConsonant {
+		conjunct = 1
+	}
+
+	for i := from + 1; i < len(runes); i++ {
+		r := runes[i]
+		cur := graphemeBreakProperty(r)
+		incb := indicConjunctProperty(r)
+		isPict := unicode.Is(extendedPictographic, r)
+
+		var join bool
+		switch {
+		case prev == gbCR && cur == gbLF: // GB3
+			join = true
+		case prev == gbCR || prev == gbLF || prev == gbControl: // GB4
+			join = false
+		case cur == gbCR || cur == gbLF || cur == gbControl: // GB5
+			join = false
+		case prev == gbL && (cur == gbL || cur == gbV || cur == gbLV || cur == gbLVT): // GB6
+			join = true
+		case (prev == gbLV || prev == gbV) && (cur == gbV || cur == gbT): // GB7
+			join = true
+		case (prev == gbLVT || prev == gbT) && cur == gbT: // GB8
+			join = true
+		case cur == gbExtend || cur == gbZWJ || cur == gbSpacingMark: // GB9, GB9a
+			join = true
+		case prev == gbPrepend: // GB9b
+			join = true
+		case conjunct == 2 && incb == incbConsonant: // GB9c
+			join = true
+		case prev == gbZWJ && emoji == 2 && isPict: // GB11
+			join = true
+		case prev == gbRegionalIndicator && cur == gbRegionalIndicator: // GB12, GB13
+			join = riCount%2 == 1
+		}
+		if !join {
+			return i
+		}
+
+		switch {
+		case isPict:
+			emoji = 1
+		case cur == gbExtend && emoji == 1:
+		case cur == gbZWJ && emoji == 1:
+			emoji = 2
+		default:
+			emoji = 0
+		}
+
+		if cur == gbRegionalIndicator {
+			riCount++
+		} else {
+			riCount = 0
+		}
+
+		switch {
+		case incb == incbConsonant:
+			conjunct = 1
+		case incb == incbLinker && conjunct > 0:
+			conjunct = 2
+		case incb == incbExtend && conjunct > 0:
+		default:
+			conjunct = 0
+		}
+
+		prev = cur
+	}
+	return len(runes)
+}
+
+// Returns display width of a single rune in monospace font:
+// 0 for control and combining characters, 2 for East Asian wide
+// and fullwidth characters, 1 otherwise
+func runeWidth(r rune) int {
+	switch {
+	case r == 0:
+		return 0
+	case unicode.In(r, unicode.Cc, unicode.Cf, unicode.Mn, unicode.Me, unicode.Zl, unicode.Zp):
+		return 0
+	case r >= 0x1160 && r <= 0x11ff: // conjoining Hangul vowels and trailing consonants
+		return 0
+	case unicode.Is(eastAsianWide, r):
+		return 2
+	}
+	return 1
+}
+
+// Returns display width of a grapheme cluster
+func graphemeWidth(cluster []rune) int {
+	for _, r := range cluster[1:] {
+		if r == 0xfe0f { // VARIATION SELECTOR-16 requests emoji presentation
+			return 2
+		}
+	}
+	if graphemeBreakProperty(cluster[0]) == gbRegionalIndicator {
+		return 2
+	}
+	for _, r := range cluster {
+		if w := runeWidth(r); w > 0 {
+			return w
+		}
+	}
+	return 0
+}
+
+// Returns position of the next grapheme cluster boundary after position `from`.
+// Grapheme cluster is a user-perceived character: base letter with combining
+// marks, emoji with modifiers or ZWJ sequence, flag, Hangul syllable, etc.
+// Returns `Length()` if there are no more boundaries
+func (s *String) NextGraphemeBreak(from int) int {
+	if from < 0 {
+		from = 0
+	}
+	return graphemeClusterEnd(s.runes, from)
+}
+
+// Returns locations of all grapheme clusters in String object
+// Location is an 2 ints array {from, to} in runes
+func (s *String) Graphemes() [][]int {
+	var locs [][]int
+	for from := 0; from < len(s.runes); {
+		to := graphemeClusterEnd(s.runes, from)
+		locs = append(locs, []int{from, to})
+		from = to
+	}
+	return locs
+}
+
+// Returns count of grapheme clusters (user-perceived characters)
+func (s *String) GraphemeLength() int {
+	cnt := 0
+	for from := 0; from < len(s.runes); cnt++ {
+		from = graphemeClusterEnd(s.runes, from)
+	}
+	return cnt
+}
+
+// Returns substring between grapheme clusters `from` and `to`
+// as a pointer to new String object (like Substring, but counts clusters)
+func (s *String) GraphemeSubstring(from, to int) *String {
+	if from < 0 || from > to {
+		return nil
+	}
+	rFrom, rTo := -1, -1
+	pos := 0
+	for cnt := 0; ; cnt++ {
+		if cnt == from {
+			rFrom = pos
+		}
+		if cnt == to {
+			rTo = pos
+			break
+		}
+		if pos == len(s.runes) {
+			break
+		}
+		pos = graphemeClusterEnd(s.runes, pos)
+	}
+	if rFrom < 0 || rTo < 0 {
+		return nil
+	}
+	return s.Substring(rFrom, rTo)
+}
+
+// Returns width of String object in cells of monospace font
+// (East Asian wide characters and emoji take two cells,
+// combining marks and control characters take none)
+func (s *String) DisplayWidth() int {
+	width := 0
+	for from := 0; from < len(s.runes); {
+		to := graphemeClusterEnd(s.runes, from)
+		width += graphemeWidth(s.runes[from:to])
+		from = to
+	}
+	return width
+}
+
+// Returns the longest prefix of String object that fits into `maxWidth`
+// cells of monospace font. String is never cut inside grapheme cluster
+func (s *String) TruncateWidth(maxWidth int) *String {
+	width := 0
+	from := 0
+	for from < len(s.runes) {
+		to := graphemeClusterEnd(s.runes, from)
+		width += graphemeWidth(s.runes[from:to])
+		if width > maxWidth {
+			break
+		}
+		from = to
+	}
+	return s.Substring(0, from)
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func BenchmarkGraphemeLength(b *testing.B) {
+	b.StopTimer()
+	s := RandStringRunes(1000000)
+	str := NewString(s)
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		_ = str.GraphemeLength()
+	}
+}
+
+func TestGraphemeClusters(t *testing.T) {
+	cases := []struct {
+		str      string
+		clusters []string
+	}{
+		{"abc", []string{"a", "b", "c"}},
+		{"été", []string{"é", "t", "é"}},
+		{"a\r\nb", []string{"a", "\r\n", "b"}},
+		{"👍🏽!", []string{"👍🏽", "!"}},
+		{"👨‍👩‍👧x", []string{"👨‍👩‍👧", "x"}},
+		{"🇺🇦🇺🇸🇩", []string{"🇺🇦", "🇺🇸", "🇩"}},
+		{"한한", []string{"한", "한"}},
+		{"नमस्ते", []string{"न", "म", "स्ते"}},
+		{"क्षि", []string{"क्षि"}},
+		{"1️⃣", []string{"1️⃣"}},
+		{"", nil},
+	}
+	for _, c := range cases {
+		str := NewString(c.str)
+		var clusters []string
+		for _, loc := range str.Graphemes() {
+			clusters = append(clusters, str.Substring(loc[0], loc[1]).String())
+		}
+		assert.Equal(t, clusters, c.clusters, c.str)
+		assert.Equal(t, str.GraphemeLength(), len(c.clusters), c.str)
+	}
+}
+
+func TestGraphemeSubstring(t *testing.T) {
+	str := NewString("ё👍🏽🇺🇦x")
+	assert.Equal(t, str.Length(), 6)
+	assert.Equal(t, str.GraphemeLength(), 4)
+	assert.Equal(t, str.NextGraphemeBreak(1), 3)
+
+	assert.Equal(t, str.GraphemeSubstring(1, 3).String(), "👍🏽🇺🇦")
+	assert.Equal(t, str.GraphemeSubstring(0, 4).String(), str.String())
+	assert.Equal(t, str.GraphemeSubstring(4, 4).Length(), 0)
+	assert.Nil(t, str.GraphemeSubstring(2, 1))
+	assert.Nil(t, str.GraphemeSubstring(-1, 1))
+	assert.Nil(t, str.GraphemeSubstring(1, 5))
+}
+
+func TestDisplayWidth(t *testing.T) {
+	assert.Equal(t, NewString("hello").DisplayWidth(), 5)
+	assert.Equal(t, NewString("こんにちは").DisplayWidth(), 10)
+	assert.Equal(t, NewString("é").DisplayWidth(), 1)
+	assert.Equal(t, NewString("👍🏽🇺🇦").DisplayWidth(), 4)
+	assert.Equal(t, NewString("❤️").DisplayWidth(), 2)
+	assert.Equal(t, NewString("한").DisplayWidth(), 2)
+	assert.Equal(t, NewString("ＡＢ").DisplayWidth(), 4)
+
+	str := NewString("abこんに👍🏽")
+	assert.Equal(t, str.TruncateWidth(5).String(), "abこ")
+	assert.Equal(t, str.TruncateWidth(6).String(), "abこん")
+	assert.Equal(t, str.TruncateWidth(9).String(), "abこんに")
+	assert.Equal(t, str.TruncateWidth(10).String(), str.String())
+	assert.Equal(t, str.TruncateWidth(0).Length(), 0)
+}
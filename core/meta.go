@@ -1,5 +1,10 @@
 package core
 
+import (
+	"reflect"
+	"sort"
+)
+
 type MetaDataKey string
 
 // `MetaData` stores map of `interface{}` with
@@ -104,3 +109,118 @@ func (m *MetaData) GetFloat(key MetaDataKey) (float64, bool) {
 		}
 	}
 }
+
+// Returns count of entries
+func (m *MetaData) Len() int {
+	return len(m.meta)
+}
+
+// Returns sorted list of keys
+func (m *MetaData) Keys() []MetaDataKey {
+	keys := make([]MetaDataKey, 0, len(m.meta))
+	for key := range m.meta {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// Returns value stored by key as is
+func (m *MetaData) Get(key MetaDataKey) (interface{}, bool) {
+	val, ok := m.meta[key]
+	return val, ok
+}
+
+// Clone returns deep copy of MetaData: slices and nested MetaData
+// values are copied too
+func (m *MetaData) Clone() *MetaData {
+	clone := NewMetaData()
+	for key, val := range m.meta {
+		clone.meta[key] = cloneMetaValue(val)
+	}
+	return clone
+}
+
+// Merge copies all entries of `other` into MetaData overwriting existing ones.
+// Nested MetaData values present in both are merged recursively,
+// nil `other` is ignored
+func (m *MetaData) Merge(other *MetaData) {
+	if other == nil {
+		return
+	}
+	for key, val := range other.meta {
+		if nested, ok := val.(*MetaData); ok && nested != nil {
+			if dst, ok := m.meta[key].(*MetaData); ok && dst != nil {
+				dst.Merge(nested)
+				continue
+			}
+		}
+		m.meta[key] = cloneMetaValue(val)
+	}
+}
+
+func cloneMetaValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case *MetaData:
+		if v == nil {
+			return v
+		}
+		return v.Clone()
+	case []*MetaData:
+		if v == nil {
+			return v
+		}
+		clone := make([]*MetaData, len(v))
+		for i, nested := range v {
+			if nested != nil {
+				clone[i] = nested.Clone()
+			}
+		}
+		return clone
+	}
+	rv := reflect.ValueOf(val)
+	if rv.Kind() == reflect.Slice && !rv.IsNil() {
+		clone := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		reflect.Copy(clone, rv)
+		return clone.Interface()
+	}
+	return val
+}
+
+// `Key` is a typed key of MetaData entry.
+// Values set by Key[T] can be got back only as T:
+//
+//	var EntityID = core.NewKey[string]("entity_id")
+//	EntityID.Set(meta, "Q42")
+//	id, ok := EntityID.Get(meta)
+type Key[T any] struct {
+	name MetaDataKey
+}
+
+// Constructor creates new typed key
+func NewKey[T any](name MetaDataKey) Key[T] {
+	return Key[T]{name: name}
+}
+
+// Returns name of key
+func (k Key[T]) Name() MetaDataKey {
+	return k.name
+}
+
+// Returns value by key and `true` if entry exists and has type T
+func (k Key[T]) Get(m *MetaData) (T, bool) {
+	typeVal, ok := m.meta[k.name].(T)
+	return typeVal, ok
+}
+
+// Sets value by key, returns `true` if entry is new
+func (k Key[T]) Set(m *MetaData, val T) bool {
+	_, ok := m.meta[k.name]
+	m.meta[k.name] = val
+	return !ok
+}
+
+// Deletes entry by key
+func (k Key[T]) Del(m *MetaData) bool {
+	return m.Del(k.name)
+}
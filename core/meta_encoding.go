@@ -0,0 +1,181 @@
+package core
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Error type returned when MetaData contains value
+// of type that can't be serialized
+type UnsupportedMetaTypeError struct {
+	Key  MetaDataKey
+	Type string
+}
+
+// Returns error description for UnsupportedMetaTypeError
+func (e UnsupportedMetaTypeError) Error() string {
+	return fmt.Sprintf("unsupported type %s of metadata key %q", e.Type, e.Key)
+}
+
+// Serialized MetaData entry, type name is stored with value
+// to restore exactly the same Go type on decoding
+type metaEntry struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Decoders of serializable types by type name
+var metaDecoders = map[string]func([]byte) (interface{}, error){
+	"bool":      decodeMetaValue[bool],
+	"string":    decodeMetaValue[string],
+	"int":       decodeMetaValue[int],
+	"int64":     decodeMetaValue[int64],
+	"float64":   decodeMetaValue[float64],
+	"time":      decodeMetaValue[time.Time],
+	"[]bool":    decodeMetaValue[[]bool],
+	"[]string":  decodeMetaValue[[]string],
+	"[]int":     decodeMetaValue[[]int],
+	"[]int64":   decodeMetaValue[[]int64],
+	"[]float64": decodeMetaValue[[]float64],
+	"[]time":    decodeMetaValue[[]time.Time],
+	"meta":      decodeMetaValue[*MetaData],
+	"[]meta":    decodeMetaValue[[]*MetaData],
+}
+
+func init() {
+	gob.Register(time.Time{})
+	gob.Register([]time.Time{})
+	gob.Register(&MetaData{})
+	gob.Register([]*MetaData{})
+}
+
+func decodeMetaValue[T any](data []byte) (interface{}, error) {
+	var val T
+	err := json.Unmarshal(data, &val)
+	return val, err
+}
+
+// Returns name of serializable type of value
+func metaTypeName(val interface{}) (string, bool) {
+	switch val.(type) {
+	case bool:
+		return "bool", true
+	case string:
+		return "string", true
+	case int:
+		return "int", true
+	case int64:
+		return "int64", true
+	case float64:
+		return "float64", true
+	case time.Time:
+		return "time", true
+	case []bool:
+		return "[]bool", true
+	case []string:
+		return "[]string", true
+	case []int:
+		return "[]int", true
+	case []int64:
+		return "[]int64", true
+	case []float64:
+		return "[]float64", true
+	case []time.Time:
+		return "[]time", true
+	case *MetaData:
+		return "meta", true
+	case []*MetaData:
+		return "[]meta", true
+	}
+	return "", false
+}
+
+// Checks that all values (including nested ones) can be serialized
+func (m *MetaData) validate() error {
+	for key, val := range m.meta {
+		if _, ok := metaTypeName(val); !ok {
+			return UnsupportedMetaTypeError{key, fmt.Sprintf("%T", val)}
+		}
+		switch v := val.(type) {
+		case *MetaData:
+			if err := v.validate(); err != nil {
+				return err
+			}
+		case []*MetaData:
+			for _, nested := range v {
+				if err := nested.validate(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler interface.
+// Every entry is encoded as {"type": ..., "value": ...}, so types are
+// preserved. Supported types are bool, string, int, int64, float64,
+// time.Time, slices of them, *MetaData and []*MetaData
+func (m *MetaData) MarshalJSON() ([]byte, error) {
+	entries := make(map[MetaDataKey]metaEntry, len(m.meta))
+	for key, val := range m.meta {
+		typeName, ok := metaTypeName(val)
+		if !ok {
+			return nil, UnsupportedMetaTypeError{key, fmt.Sprintf("%T", val)}
+		}
+		data, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		entries[key] = metaEntry{Type: typeName, Value: data}
+	}
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface
+func (m *MetaData) UnmarshalJSON(data []byte) error {
+	var entries map[MetaDataKey]metaEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	meta := make(map[MetaDataKey]interface{}, len(entries))
+	for key, entry := range entries {
+		decodeFn, ok := metaDecoders[entry.Type]
+		if !ok {
+			return UnsupportedMetaTypeError{key, entry.Type}
+		}
+		val, err := decodeFn(entry.Value)
+		if err != nil {
+			return fmt.Errorf("metadata key %q: %v", key, err)
+		}
+		meta[key] = val
+	}
+	m.meta = meta
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder interface,
+// supported types are the same as for MarshalJSON
+func (m *MetaData) GobEncode() ([]byte, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(m.meta); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder interface
+func (m *MetaData) GobDecode(data []byte) error {
+	meta := make(map[MetaDataKey]interface{})
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&meta); err != nil {
+		return err
+	}
+	m.meta = meta
+	return nil
+}
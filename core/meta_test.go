@@ -1,8 +1,12 @@
 package core
 
 import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 const (
@@ -78,3 +82,147 @@ func TestFloatValues(t *testing.T) {
 	_, nok = meta.GetFloat("unknown")
 	assert.False(t, nok)
 }
+
+var (
+	NameKey    = NewKey[string]("name")
+	ScoresKey  = NewKey[[]float64]("scores")
+	CreatedKey = NewKey[time.Time]("created")
+	NestedKey  = NewKey[*MetaData]("nested")
+)
+
+func TestTypedKeys(t *testing.T) {
+	meta := NewMetaData()
+	assert.True(t, NameKey.Set(meta, "Kyiv"))
+	assert.False(t, NameKey.Set(meta, "Kharkiv"))
+	val, ok := NameKey.Get(meta)
+	assert.True(t, ok)
+	assert.Equal(t, val, "Kharkiv")
+	str, ok := meta.GetString(NameKey.Name())
+	assert.True(t, ok)
+	assert.Equal(t, str, "Kharkiv")
+
+	ScoresKey.Set(meta, []float64{0.5, 0.25})
+	scores, ok := ScoresKey.Get(meta)
+	assert.True(t, ok)
+	assert.Equal(t, scores, []float64{0.5, 0.25})
+
+	// the same name but another type
+	_, ok = NewKey[int]("name").Get(meta)
+	assert.False(t, ok)
+	_, ok = CreatedKey.Get(meta)
+	assert.False(t, ok)
+
+	assert.True(t, NameKey.Del(meta))
+	assert.False(t, NameKey.Del(meta))
+	assert.Equal(t, meta.Keys(), []MetaDataKey{"scores"})
+}
+
+func newTestMetaData() *MetaData {
+	nested := NewMetaData()
+	nested.SetInt(IntKey, 42)
+	nested.SetBool(BoolKey, true)
+
+	meta := NewMetaData()
+	meta.SetString(StringKey, "ёжик")
+	meta.SetFloat(FloatKey, 0.125)
+	meta.SetInt(IntKey, 7)
+	ScoresKey.Set(meta, []float64{1, 2.5})
+	CreatedKey.Set(meta, time.Date(2016, 7, 1, 12, 30, 0, 0, time.UTC))
+	NestedKey.Set(meta, nested)
+	NewKey[[]string]("tags").Set(meta, []string{"NN", "VB"})
+	NewKey[int64]("id").Set(meta, int64(1)<<40)
+	return meta
+}
+
+func TestMetaDataJSON(t *testing.T) {
+	meta := newTestMetaData()
+
+	data, err := json.Marshal(meta)
+	assert.NoError(t, err)
+
+	decoded := NewMetaData()
+	assert.NoError(t, json.Unmarshal(data, decoded))
+	assert.Equal(t, decoded, meta)
+
+	// int is not decoded as float64
+	val, ok := decoded.GetInt(IntKey)
+	assert.True(t, ok)
+	assert.Equal(t, val, 7)
+
+	NewKey[complex128]("complex").Set(meta, 1i)
+	_, err = json.Marshal(meta)
+	assert.Error(t, err)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"a":{"type":"chan","value":1}}`), decoded))
+}
+
+func TestMetaDataGob(t *testing.T) {
+	meta := newTestMetaData()
+
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(meta))
+
+	decoded := NewMetaData()
+	assert.NoError(t, gob.NewDecoder(&buf).Decode(decoded))
+	assert.Equal(t, decoded, meta)
+
+	nested, _ := NestedKey.Get(meta)
+	NewKey[complex128]("complex").Set(nested, 1i)
+	assert.Error(t, gob.NewEncoder(&buf).Encode(meta))
+}
+
+func TestCloneMerge(t *testing.T) {
+	meta := newTestMetaData()
+	clone := meta.Clone()
+	assert.Equal(t, clone, meta)
+
+	scores, _ := ScoresKey.Get(clone)
+	scores[0] = 100
+	nested, _ := NestedKey.Get(clone)
+	nested.SetInt(IntKey, 0)
+	origScores, _ := ScoresKey.Get(meta)
+	assert.Equal(t, origScores, []float64{1, 2.5})
+	origNested, _ := NestedKey.Get(meta)
+	val, _ := origNested.GetInt(IntKey)
+	assert.Equal(t, val, 42)
+
+	other := NewMetaData()
+	other.SetString(StringKey, "other")
+	otherNested := NewMetaData()
+	otherNested.SetString(StringKey, "nested")
+	NestedKey.Set(other, otherNested)
+
+	meta.Merge(other)
+	str, _ := meta.GetString(StringKey)
+	assert.Equal(t, str, "other")
+	nested, _ = NestedKey.Get(meta)
+	assert.Equal(t, nested.Keys(), []MetaDataKey{BoolKey, IntKey, StringKey})
+	assert.Equal(t, meta.Len(), 8)
+}
+
+func TestCloneMergeNil(t *testing.T) {
+	meta := NewMetaData()
+	NestedKey.Set(meta, nil)
+	listKey := NewKey[[]*MetaData]("list")
+	listKey.Set(meta, []*MetaData{nil, NewMetaData()})
+
+	clone := meta.Clone()
+	nested, ok := NestedKey.Get(clone)
+	assert.True(t, ok)
+	assert.Nil(t, nested)
+	list, _ := listKey.Get(clone)
+	assert.Len(t, list, 2)
+	assert.Nil(t, list[0])
+	assert.NotNil(t, list[1])
+
+	// nil nested value is overwritten, nil other is ignored
+	other := NewMetaData()
+	otherNested := NewMetaData()
+	otherNested.SetInt(IntKey, 1)
+	NestedKey.Set(other, otherNested)
+	meta.Merge(other)
+	meta.Merge(nil)
+	nested, _ = NestedKey.Get(meta)
+	val, _ := nested.GetInt(IntKey)
+	assert.Equal(t, val, 1)
+}
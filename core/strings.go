@@ -20,27 +20,28 @@
 //
 //   newS, cnt := ustr.Replace(re, "Ё")  // cnt == 4, newS.String() == "thЁs Ёs unЁcode strЁng: こんにちは""
 //
+//   re, _ = regexp.Compile(`(\w+) (\w+)`)
+//   groups := ustr.FindSubmatch(re)  // groups == []int{0, 7, 0, 4, 5, 7}
+//   newS, _ = ustr.ReplaceTemplate(re, "$2 $1")  // newS.String() == "is this unicode string: こんにちは"
+//
+//   re, _ = regexp.Compile(`\s+`)
+//   parts := ustr.Split(re, -1)  // 5 String objects, one for every word
+//
+// All locations returned by String methods are rune offsets.
+// String objects are never modified by searching, so one String
+// can be matched from several goroutines at once.
+//
 
 package core
 
 import (
 	"regexp"
+	"unicode/utf8"
 )
 
-// Error type that returns ReadRune function
-// when end of string occured
-type noRuneError struct {
-}
-
-// Returns error description for noRuneError
-func (e noRuneError) Error() string {
-	return "no next rune in string"
-}
-
 // Representation of string as an array of runes.
 type String struct {
 	runes []rune
-	ridx  int // used for runes indexing in regexp
 }
 
 // Creates new String object from `string`
@@ -69,96 +70,173 @@ func (s *String) Substring(from, to int) *String {
 	return &new_s
 }
 
-// This function is a part of io.RuneReader iterface
-// that used in regexp
-func (s *String) ReadRune() (r rune, size int, err error) {
-	if s.ridx == len(s.runes) {
-		return 0, 0, noRuneError{}
+// This internal function converts bytes locations returned by regexp
+// for `str` (which is String object converted to `string`)
+// to runes locations. Unmatched groups (-1) are kept as is.
+func (s *String) normalizeRegexpLocs(str string, b_locs [][]int) [][]int {
+	locs := make([][]int, len(b_locs))
+	b_pos, r_pos := 0, 0
+	for i, b_loc := range b_locs {
+		// matches are sorted, so we can move cursor forward only
+		r_pos += utf8.RuneCountInString(str[b_pos:b_loc[0]])
+		b_pos = b_loc[0]
+
+		loc := make([]int, len(b_loc))
+		for j, b := range b_loc {
+			if b < 0 {
+				loc[j] = -1
+				continue
+			}
+			// submatches never start before the whole match
+			loc[j] = r_pos + utf8.RuneCountInString(str[b_pos:b])
+		}
+		locs[i] = loc
 	}
-	r = s.runes[s.ridx]
-	err = nil
-	s.ridx += 1
-	// padding to 4 bytes per rune
-	// expecting offset returned from regexp.FindReaderIndex equal to rune_offset*4
-	size = 4
-	return
+	return locs
 }
 
-// This internal function convert bytes location to runes location in string
-func (s *String) normalizeRegexpLoc(runes_offset, from, to int) (loc []int) {
-	from_rune := runes_offset + (from / 4)
-	to_rune := runes_offset + (to / 4)
-	loc = []int{from_rune, to_rune}
-	return loc
+// Finds up to `n` submatches locations (n < 0 means all)
+func (s *String) findAll(re *regexp.Regexp, n int) [][]int {
+	str := string(s.runes)
+	b_locs := re.FindAllStringSubmatchIndex(str, n)
+	if b_locs == nil {
+		return nil
+	}
+	return s.normalizeRegexpLocs(str, b_locs)
 }
 
 // Finds first substring location found by regexp
 // If not found - returns `nil`
 // Location is an 2 bytes array {from, to}
 func (s *String) FindFirst(re *regexp.Regexp) (loc []int) {
-	s.ridx = 0
-	b_loc := re.FindReaderIndex(s)
-	if b_loc == nil {
-		s.ridx = 0
+	locs := s.findAll(re, 1)
+	if locs == nil {
 		return nil
 	}
-	s.ridx = 0
-	return s.normalizeRegexpLoc(0, b_loc[0], b_loc[1])
+	return locs[0][:2]
 }
 
 // Finds all substrings locations in String object by regexp
 // FindAll function returns array of locations (location is an 2 bytes array {from,to})
 func (s *String) FindAll(re *regexp.Regexp) [][]int {
-	s.ridx = 0
-	var ret_locs [][]int
-	var cur_ridx int
-	for {
-		cur_ridx = s.ridx
-		b_loc := re.FindReaderIndex(s)
-		if b_loc == nil {
-			s.ridx = 0
-			break
-		} else {
-			s.ridx = cur_ridx + b_loc[1]/4
-		}
-		ret_locs = append(ret_locs, s.normalizeRegexpLoc(cur_ridx, b_loc[0], b_loc[1]))
+	locs := s.findAll(re, -1)
+	for i, loc := range locs {
+		locs[i] = loc[:2]
+	}
+	return locs
+}
 
+// Finds first match and locations of its capture groups
+// Returns array {from, to, group1_from, group1_to, ...}
+// in the same manner as regexp.FindStringSubmatchIndex does.
+// Groups that didn't participate in match have -1 locations.
+// If not found - returns `nil`
+func (s *String) FindSubmatch(re *regexp.Regexp) []int {
+	locs := s.findAll(re, 1)
+	if locs == nil {
+		return nil
 	}
-	return ret_locs
+	return locs[0]
+}
+
+// Finds all matches and locations of their capture groups
+// Every item of returned array has the same layout as FindSubmatch result
+func (s *String) FindAllSubmatch(re *regexp.Regexp) [][]int {
+	return s.findAll(re, -1)
 }
 
 // Match reports whether the regexp matches the String object
 // returns `true` if matched and `false` otherwise
 func (s *String) Match(re *regexp.Regexp) bool {
-	s.ridx = 0
-	matched := re.MatchReader(s)
-	s.ridx = 0
-	return matched
+	return re.MatchString(string(s.runes))
+}
+
+// This internal function builds new String object where every location
+// from `locs` is replaced by runes returned from `replFn`
+func (s *String) replaceLocs(locs [][]int, replFn func(loc []int) []rune) *String {
+	runes := make([]rune, 0, len(s.runes))
+	s_idx := 0
+	for _, loc := range locs {
+		runes = append(runes, s.runes[s_idx:loc[0]]...)
+		runes = append(runes, replFn(loc)...)
+		s_idx = loc[1]
+	}
+	runes = append(runes, s.runes[s_idx:]...)
+	return &String{runes: runes}
 }
 
 // Replace finds substrings in String object by regexp and replace it by `newText`
 // Returns new String object and count of replaced strings
 func (s *String) Replace(re *regexp.Regexp, newText string) (*String, int) {
-	newStr := NewString(newText)
+	newRunes := []rune(newText)
 	locs := s.FindAll(re)
-	cut_len := 0
-	for _, loc := range locs {
-		cut_len += loc[1] - loc[0]
+	replFn := func(loc []int) []rune {
+		return newRunes
 	}
-	nlen := newStr.Length()
-	new_len := nlen*len(locs) + s.Length() - cut_len
-	runes := make([]rune, new_len)
-	s_idx := 0
-	d_idx := 0
-	d_end := 0
+	return s.replaceLocs(locs, replFn), len(locs)
+}
+
+// ReplaceFunc finds substrings in String object by regexp and replace every
+// substring by String object returned from `fn` called with matched substring
+// Returns new String object and count of replaced strings
+func (s *String) ReplaceFunc(re *regexp.Regexp, fn func(*String) *String) (*String, int) {
+	locs := s.FindAll(re)
+	replFn := func(loc []int) []rune {
+		if repl := fn(s.Substring(loc[0], loc[1])); repl != nil {
+			return repl.runes
+		}
+		return nil
+	}
+	return s.replaceLocs(locs, replFn), len(locs)
+}
+
+// ReplaceTemplate finds substrings in String object by regexp and replace them
+// by `template` where $1, ${name} etc. are expanded to capture groups
+// (see regexp.Expand for syntax)
+// Returns new String object and count of replaced strings
+func (s *String) ReplaceTemplate(re *regexp.Regexp, template string) (*String, int) {
+	str := string(s.runes)
+	b_locs := re.FindAllStringSubmatchIndex(str, -1)
+	locs := s.normalizeRegexpLocs(str, b_locs)
+	dst := []byte{}
+	idx := 0
+	replFn := func(loc []int) []rune {
+		dst = re.ExpandString(dst[:0], template, str, b_locs[idx])
+		idx++
+		return []rune(string(dst))
+	}
+	return s.replaceLocs(locs, replFn), len(locs)
+}
+
+// Split slices String object into substrings separated by regexp
+// `n` has the same meaning as in regexp.Split: n > 0 - at most n substrings
+// (the last one is the unsplit remainder), n == 0 - nil, n < 0 - all substrings
+func (s *String) Split(re *regexp.Regexp, n int) []*String {
+	if n == 0 {
+		return nil
+	}
+	if len(re.String()) > 0 && len(s.runes) == 0 {
+		return []*String{s.Substring(0, 0)}
+	}
+
+	locs := s.FindAll(re)
+	parts := make([]*String, 0, len(locs)+1)
+
+	beg, end := 0, 0
 	for _, loc := range locs {
-		d_end = d_idx + loc[0] - s_idx
-		copy(runes[d_idx:d_end], s.runes[s_idx:loc[0]])
-		copy(runes[d_end:d_end+nlen], newStr.runes)
-		s_idx = loc[1]
-		d_idx = d_end + nlen
+		if n > 0 && len(parts) == n-1 {
+			break
+		}
+		end = loc[0]
+		if loc[1] == 0 {
+			// skip empty match at the beginning of string
+			continue
+		}
+		parts = append(parts, s.Substring(beg, end))
+		beg = loc[1]
+	}
+	if end != len(s.runes) {
+		parts = append(parts, s.Substring(beg, len(s.runes)))
 	}
-	copy(runes[d_idx:], s.runes[s_idx:])
-	new := String{runes: runes}
-	return &new, len(locs)
+	return parts
 }
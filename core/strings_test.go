@@ -5,6 +5,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"math/rand"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -162,3 +164,94 @@ func TestBasic(t *testing.T) {
 	assert.Equal(t, cnt, 0)
 	assert.Equal(t, newS.String(), str.String())
 }
+
+func TestSubmatch(t *testing.T) {
+	str := NewString("こんに 2016-07, ёжик 2017-01")
+	re := regexp.MustCompile(`(\d+)-(\d+)`)
+
+	loc := str.FindSubmatch(re)
+	assert.Equal(t, loc, []int{4, 11, 4, 8, 9, 11})
+	assert.Equal(t, str.Substring(loc[2], loc[3]).String(), "2016")
+
+	locs := str.FindAllSubmatch(re)
+	assert.Equal(t, len(locs), 2)
+	assert.Equal(t, locs[1], []int{18, 25, 18, 22, 23, 25})
+	assert.Equal(t, str.Substring(locs[1][4], locs[1][5]).String(), "01")
+
+	re = regexp.MustCompile(`(ё)|(ж)`)
+	loc = str.FindSubmatch(re)
+	assert.Equal(t, loc, []int{13, 14, 13, 14, -1, -1})
+
+	assert.Nil(t, str.FindSubmatch(regexp.MustCompile(`xyz`)))
+	assert.Nil(t, str.FindAllSubmatch(regexp.MustCompile(`xyz`)))
+
+	// anchors are not reapplied for every match
+	locs = NewString("ааа").FindAll(regexp.MustCompile(`^а`))
+	assert.Equal(t, locs, [][]int{{0, 1}})
+}
+
+func TestReplaceTemplateFunc(t *testing.T) {
+	str := NewString("こんに 2016-07, ёжик 2017-01")
+	re := regexp.MustCompile(`(\d+)-(?P<month>\d+)`)
+
+	newS, cnt := str.ReplaceTemplate(re, "${month}/$1")
+	assert.Equal(t, cnt, 2)
+	assert.Equal(t, newS.String(), "こんに 07/2016, ёжик 01/2017")
+
+	newS, cnt = str.ReplaceFunc(regexp.MustCompile(`[а-яё]+`), func(m *String) *String {
+		return NewString(strings.ToUpper(m.String()))
+	})
+	assert.Equal(t, cnt, 1)
+	assert.Equal(t, newS.String(), "こんに 2016-07, ЁЖИК 2017-01")
+
+	newS, cnt = str.ReplaceFunc(re, func(m *String) *String {
+		return nil
+	})
+	assert.Equal(t, cnt, 2)
+	assert.Equal(t, newS.String(), "こんに , ёжик ")
+}
+
+func TestSplit(t *testing.T) {
+	str := NewString("ёжик,  こんに ,hello")
+	re := regexp.MustCompile(`\s*,\s*`)
+
+	parts := str.Split(re, -1)
+	assert.Equal(t, len(parts), 3)
+	assert.Equal(t, parts[0].String(), "ёжик")
+	assert.Equal(t, parts[1].String(), "こんに")
+	assert.Equal(t, parts[2].String(), "hello")
+
+	parts = str.Split(re, 2)
+	assert.Equal(t, len(parts), 2)
+	assert.Equal(t, parts[1].String(), "こんに ,hello")
+
+	assert.Nil(t, str.Split(re, 0))
+
+	parts = NewString("").Split(re, -1)
+	assert.Equal(t, len(parts), 1)
+	assert.Equal(t, parts[0].Length(), 0)
+
+	parts = NewString("ёж").Split(regexp.MustCompile(``), -1)
+	assert.Equal(t, len(parts), 2)
+	assert.Equal(t, parts[0].String(), "ё")
+	assert.Equal(t, parts[1].String(), "ж")
+}
+
+func TestConcurrentMatching(t *testing.T) {
+	str := NewString(RandStringRunes(10000))
+	re := regexp.MustCompile("[йцук]")
+	expected := str.FindAll(re)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				assert.Equal(t, str.FindAll(re), expected)
+				assert.True(t, str.Match(re))
+			}
+		}()
+	}
+	wg.Wait()
+}
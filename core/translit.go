@@ -0,0 +1,173 @@
+package core
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Transliteration scheme maps letters (or short letter sequences)
+// of one script to strings of another. Tables are defined for lower case,
+// upper case is restored by Transliterate
+type TranslitScheme struct {
+	table        map[string]string
+	contextual   map[string]string
+	contextRunes []rune
+	maxKey       int
+}
+
+// Constructor creates new transliteration scheme.
+// `table` maps lower case letters or sequences to their transliteration,
+// `contextual` (may be nil) overrides `table` at the beginning of a word
+// or after any of `contextRunes` (e.g. Russian "е" is "ye" after vowels
+// in BGN/PCGN romanization)
+func NewTranslitScheme(table, contextual map[string]string, contextRunes string) *TranslitScheme {
+	ts := &TranslitScheme{
+		table:        table,
+		contextual:   contextual,
+		contextRunes: []rune(contextRunes),
+	}
+	for _, tbl := range []map[string]string{table, contextual} {
+		for key := range tbl {
+			if l := len([]rune(key)); l > ts.maxKey {
+				ts.maxKey = l
+			}
+		}
+	}
+	return ts
+}
+
+// Cyrillic to Latin by ISO 9:1995 (GOST 7.79-2000 System A),
+// one Latin letter (with diacritics) for every Cyrillic one
+var TranslitISO9 = NewTranslitScheme(map[string]string{
+	"а": "a", "б": "b", "в": "v", "г": "g", "д": "d", "е": "e", "ё": "ë",
+	"ж": "ž", "з": "z", "и": "i", "й": "j", "к": "k", "л": "l", "м": "m",
+	"н": "n", "о": "o", "п": "p", "р": "r", "с": "s", "т": "t", "у": "u",
+	"ф": "f", "х": "h", "ц": "c", "ч": "č", "ш": "š", "щ": "ŝ", "ъ": "ʺ",
+	"ы": "y", "ь": "ʹ", "э": "è", "ю": "û", "я": "â",
+	"ґ": "g̀", "є": "ê", "і": "ì", "ї": "ï", "ў": "ǔ",
+	"ѓ": "ǵ", "ђ": "đ", "ј": "ǰ", "љ": "l̂", "њ": "n̂", "ћ": "ć", "ќ": "ḱ",
+	"џ": "d̂", "ѕ": "ẑ",
+}, nil, "")
+
+// Cyrillic to Latin by GOST 7.79-2000 System B (ASCII only)
+var TranslitGOST779B = NewTranslitScheme(map[string]string{
+	"а": "a", "б": "b", "в": "v", "г": "g", "д": "d", "е": "e", "ё": "yo",
+	"ж": "zh", "з": "z", "и": "i", "й": "j", "к": "k", "л": "l", "м": "m",
+	"н": "n", "о": "o", "п": "p", "р": "r", "с": "s", "т": "t", "у": "u",
+	"ф": "f", "х": "x", "ц": "cz", "ч": "ch", "ш": "sh", "щ": "shh", "ъ": "``",
+	"ы": "y'", "ь": "`", "э": "e'", "ю": "yu", "я": "ya",
+	"ґ": "g'", "є": "ye", "і": "i", "ї": "yi", "ў": "u`",
+	// "ц" is "c" before "i", "e", "y" and "j"
+	"це": "ce", "ци": "ci", "цы": "cy'", "цй": "cj", "ці": "ci", "цє": "cye", "цї": "cyi",
+}, nil, "")
+
+// Cyrillic (Russian) to Latin by BGN/PCGN 1947 romanization
+var TranslitBGN = NewTranslitScheme(map[string]string{
+	"а": "a", "б": "b", "в": "v", "г": "g", "д": "d", "е": "e", "ё": "ë",
+	"ж": "zh", "з": "z", "и": "i", "й": "y", "к": "k", "л": "l", "м": "m",
+	"н": "n", "о": "o", "п": "p", "р": "r", "с": "s", "т": "t", "у": "u",
+	"ф": "f", "х": "kh", "ц": "ts", "ч": "ch", "ш": "sh", "щ": "shch", "ъ": "”",
+	"ы": "y", "ь": "’", "э": "e", "ю": "yu", "я": "ya",
+}, map[string]string{
+	"е": "ye", "ё": "yë",
+}, "аеёиоуыэюяйъь")
+
+// Greek to Latin by ELOT 743 (ISO 843 transcription)
+var TranslitGreek = NewTranslitScheme(map[string]string{
+	"α": "a", "β": "v", "γ": "g", "δ": "d", "ε": "e", "ζ": "z", "η": "i",
+	"θ": "th", "ι": "i", "κ": "k", "λ": "l", "μ": "m", "ν": "n", "ξ": "x",
+	"ο": "o", "π": "p", "ρ": "r", "σ": "s", "ς": "s", "τ": "t", "υ": "y",
+	"φ": "f", "χ": "ch", "ψ": "ps", "ω": "o",
+	"αυ": "av", "ευ": "ev", "ηυ": "iv", "ου": "ou",
+	"γγ": "ng", "γξ": "nx", "γχ": "nch", "μπ": "mp",
+}, map[string]string{
+	"μπ": "b",
+}, "")
+
+// Looks up transliteration of `key` taking context into account
+func (ts *TranslitScheme) lookup(key string, inContext bool) (string, bool) {
+	if inContext {
+		if val, ok := ts.contextual[key]; ok {
+			return val, true
+		}
+	}
+	val, ok := ts.table[key]
+	return val, ok
+}
+
+// Reports whether position `i` is the beginning of a word or follows a context rune
+func (ts *TranslitScheme) inContext(runes []rune, i int) bool {
+	if i == 0 || !unicode.IsLetter(runes[i-1]) {
+		return true
+	}
+	prev := unicode.ToLower(runes[i-1])
+	for _, r := range ts.contextRunes {
+		if r == prev {
+			return true
+		}
+	}
+	return false
+}
+
+// Transliterate converts String object to another script by `scheme`.
+// Characters not covered by scheme are copied as is.
+// Returns new String object and its alignment to the original one
+func (s *String) Transliterate(scheme *TranslitScheme) (*String, []int) {
+	runes := make([]rune, 0, len(s.runes))
+	align := make([]int, 0, len(s.runes))
+
+	for i := 0; i < len(s.runes); {
+		inContext := scheme.inContext(s.runes, i)
+
+		var repl string
+		var found bool
+		l := scheme.maxKey
+		if l > len(s.runes)-i {
+			l = len(s.runes) - i
+		}
+		for ; l > 0; l-- {
+			key := s.runes[i : i+l]
+			if repl, found = scheme.lookup(strings.ToLower(string(key)), inContext); found {
+				break
+			}
+			// letters with accents missing in scheme are
+			// transliterated as base letters (e.g. Greek "ά")
+			var stripped []rune
+			for _, r := range key {
+				stripped = appendStripped(stripped, r)
+			}
+			if repl, found = scheme.lookup(strings.ToLower(string(stripped)), inContext); found {
+				break
+			}
+		}
+		if !found {
+			runes = append(runes, s.runes[i])
+			align = append(align, i)
+			i++
+			continue
+		}
+
+		if unicode.IsUpper(s.runes[i]) && repl != "" {
+			// whole word in upper case gives "SHCH", otherwise "Shch"
+			var allUpper bool
+			if i+l < len(s.runes) && unicode.IsLetter(s.runes[i+l]) {
+				allUpper = unicode.IsUpper(s.runes[i+l])
+			} else if i > 0 {
+				allUpper = unicode.IsUpper(s.runes[i-1])
+			}
+			if allUpper || l > 1 && unicode.IsUpper(s.runes[i+1]) {
+				repl = strings.ToUpper(repl)
+			} else {
+				replRunes := []rune(repl)
+				replRunes[0] = unicode.ToTitle(replRunes[0])
+				repl = string(replRunes)
+			}
+		}
+		for _, r := range repl {
+			runes = append(runes, r)
+			align = append(align, i)
+		}
+		i += l
+	}
+	return &String{runes: runes}, align
+}
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTransliterateCyrillic(t *testing.T) {
+	str := NewString("Щукин съел ёжика, ЩУКА!")
+
+	res, _ := str.Transliterate(TranslitISO9)
+	assert.Equal(t, res.String(), "Ŝukin sʺel ëžika, ŜUKA!")
+
+	res, _ = str.Transliterate(TranslitGOST779B)
+	assert.Equal(t, res.String(), "Shhukin s``el yozhika, SHHUKA!")
+
+	res, align := str.Transliterate(TranslitBGN)
+	assert.Equal(t, res.String(), "Shchukin s”yel yëzhika, SHCHUKA!")
+	assert.Equal(t, align[:6], []int{0, 0, 0, 0, 1, 2})
+	assert.Equal(t, align[11:13], []int{8, 8})
+
+	res, align = NewString("Цирк и цапля").Transliterate(TranslitGOST779B)
+	assert.Equal(t, res.String(), "Cirk i czaplya")
+	assert.Equal(t, align[:3], []int{0, 0, 2})
+}
+
+func TestTransliterateGreek(t *testing.T) {
+	res, _ := NewString("Μπαίνω στο αυτοκίνητο, Αθήνα").Transliterate(TranslitGreek)
+	assert.Equal(t, res.String(), "Baino sto avtokinito, Athina")
+}
+
+func TestCustomTranslitScheme(t *testing.T) {
+	scheme := NewTranslitScheme(map[string]string{"ä": "ae", "ö": "oe", "ü": "ue", "ß": "ss"}, nil, "")
+	res, align := NewString("Größe ÜBER").Transliterate(scheme)
+	assert.Equal(t, res.String(), "Groesse UEBER")
+	assert.Equal(t, align, []int{0, 1, 2, 2, 3, 3, 4, 5, 6, 6, 7, 8, 9})
+}
@@ -0,0 +1,271 @@
+package core
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+	"sync"
+)
+
+// Vocabulary maps strings (words, feature names, tags) to sequential
+// int32 IDs and back, so models can use integer IDs instead of strings.
+//
+// Growing vocabulary adds unknown strings on Add, frozen one returns
+// ID of unknown entry (or -1 if vocabulary has no unknown entry).
+// Vocabulary is safe for concurrent use
+type Vocabulary struct {
+	mutex     sync.RWMutex
+	ids       map[string]int32
+	words     []string
+	counts    []int
+	frozen    bool
+	unknownID int32
+}
+
+// Serializable state of Vocabulary
+type vocabularyDump struct {
+	Words     []string
+	Counts    []int
+	Frozen    bool
+	UnknownID int32
+}
+
+// Constructor creates new growing vocabulary.
+// If `unknown` is not empty, it's added with ID 0 and its ID is returned
+// for strings missing in vocabulary
+func NewVocabulary(unknown string) *Vocabulary {
+	v := &Vocabulary{
+		ids:       make(map[string]int32),
+		unknownID: -1,
+	}
+	if unknown != "" {
+		v.unknownID = v.add(unknown)
+	}
+	return v
+}
+
+func (v *Vocabulary) add(word string) int32 {
+	id := int32(len(v.words))
+	v.ids[word] = id
+	v.words = append(v.words, word)
+	v.counts = append(v.counts, 0)
+	return id
+}
+
+// Add returns ID of `word` and increments its frequency.
+// Growing vocabulary adds new words, frozen one returns UnknownID for them
+func (v *Vocabulary) Add(word string) int32 {
+	return v.AddCount(word, 1)
+}
+
+// AddCount is like Add but increases frequency of `word` by `count`,
+// e.g. to merge counts of another vocabulary
+func (v *Vocabulary) AddCount(word string, count int) int32 {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	id, ok := v.ids[word]
+	if !ok {
+		if v.frozen {
+			return v.unknownID
+		}
+		id = v.add(word)
+	}
+	v.counts[id] += count
+	return id
+}
+
+// ID returns ID of `word` and `true` if it's in vocabulary,
+// or UnknownID and `false` otherwise. Vocabulary is never modified
+func (v *Vocabulary) ID(word string) (int32, bool) {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	if id, ok := v.ids[word]; ok {
+		return id, true
+	}
+	return v.unknownID, false
+}
+
+// Word returns string by ID or empty string if ID is out of range
+func (v *Vocabulary) Word(id int32) string {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	if id < 0 || int(id) >= len(v.words) {
+		return ""
+	}
+	return v.words[id]
+}
+
+// Count returns frequency of word by ID (count of Add calls)
+func (v *Vocabulary) Count(id int32) int {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	if id < 0 || int(id) >= len(v.counts) {
+		return 0
+	}
+	return v.counts[id]
+}
+
+// Returns count of entries (including unknown one)
+func (v *Vocabulary) Len() int {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	return len(v.words)
+}
+
+// Returns ID of unknown entry or -1 if vocabulary has no one
+func (v *Vocabulary) UnknownID() int32 {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	return v.unknownID
+}
+
+// Returns copy of all words ordered by ID
+func (v *Vocabulary) Words() []string {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	words := make([]string, len(v.words))
+	copy(words, v.words)
+	return words
+}
+
+// Clone returns independent copy of vocabulary
+func (v *Vocabulary) Clone() *Vocabulary {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	clone := &Vocabulary{
+		ids:       make(map[string]int32, len(v.ids)),
+		words:     append([]string(nil), v.words...),
+		counts:    append([]int(nil), v.counts...),
+		frozen:    v.frozen,
+		unknownID: v.unknownID,
+	}
+	for word, id := range v.ids {
+		clone.ids[word] = id
+	}
+	return clone
+}
+
+// Freeze stops adding new words to vocabulary
+func (v *Vocabulary) Freeze() {
+	v.mutex.Lock()
+	v.frozen = true
+	v.mutex.Unlock()
+}
+
+// Unfreeze allows adding new words to vocabulary
+func (v *Vocabulary) Unfreeze() {
+	v.mutex.Lock()
+	v.frozen = false
+	v.mutex.Unlock()
+}
+
+// Reports whether vocabulary is frozen
+func (v *Vocabulary) IsFrozen() bool {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	return v.frozen
+}
+
+// Prune removes words with frequency less than `minCount` (unknown entry
+// is always kept) and renumbers remaining ones keeping their order.
+// Returns mapping of old IDs to new ones, removed words are mapped
+// to UnknownID, so arrays indexed by IDs can be remapped accordingly
+func (v *Vocabulary) Prune(minCount int) []int32 {
+	return v.Filter(func(id int32, word string, count int) bool {
+		return count >= minCount
+	})
+}
+
+// Filter removes words for which `keep` returns false (unknown entry
+// is always kept) and renumbers remaining ones like Prune.
+// `keep` must not call methods of vocabulary
+func (v *Vocabulary) Filter(keep func(id int32, word string, count int) bool) []int32 {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	mapping := make([]int32, len(v.words))
+	words := v.words[:0]
+	counts := v.counts[:0]
+	unknownID := int32(-1)
+	ids := make(map[string]int32, len(v.ids))
+
+	for id, word := range v.words {
+		count := v.counts[id]
+		if int32(id) != v.unknownID && !keep(int32(id), word, count) {
+			mapping[id] = -1
+			continue
+		}
+		newID := int32(len(words))
+		if int32(id) == v.unknownID {
+			unknownID = newID
+		}
+		mapping[id] = newID
+		ids[word] = newID
+		words = append(words, word)
+		counts = append(counts, count)
+	}
+	for id, newID := range mapping {
+		if newID < 0 {
+			mapping[id] = unknownID
+		}
+	}
+	v.words, v.counts, v.ids, v.unknownID = words, counts, ids, unknownID
+	return mapping
+}
+
+// GobEncode implements gob.GobEncoder interface,
+// so vocabulary can be saved as a part of model
+func (v *Vocabulary) GobEncode() ([]byte, error) {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	var buf bytes.Buffer
+	dump := vocabularyDump{v.words, v.counts, v.frozen, v.unknownID}
+	if err := gob.NewEncoder(&buf).Encode(dump); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder interface
+func (v *Vocabulary) GobDecode(data []byte) error {
+	var dump vocabularyDump
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&dump); err != nil {
+		return err
+	}
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	v.words, v.counts, v.frozen, v.unknownID = dump.Words, dump.Counts, dump.Frozen, dump.UnknownID
+	if len(v.counts) != len(v.words) {
+		v.counts = make([]int, len(v.words))
+	}
+	v.ids = make(map[string]int32, len(v.words))
+	for id, word := range v.words {
+		v.ids[word] = int32(id)
+	}
+	return nil
+}
+
+// Save writes vocabulary to `w`
+func (v *Vocabulary) Save(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(v)
+}
+
+// LoadVocabulary reads vocabulary saved by Save
+func LoadVocabulary(r io.Reader) (*Vocabulary, error) {
+	v := &Vocabulary{}
+	if err := gob.NewDecoder(r).Decode(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
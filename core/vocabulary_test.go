@@ -0,0 +1,119 @@
+package core
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+func TestVocabulary(t *testing.T) {
+	v := NewVocabulary("-UNK-")
+	assert.Equal(t, v.UnknownID(), int32(0))
+	assert.Equal(t, v.Add("the"), int32(1))
+	assert.Equal(t, v.Add("ёжик"), int32(2))
+	assert.Equal(t, v.Add("the"), int32(1))
+	assert.Equal(t, v.Len(), 3)
+	assert.Equal(t, v.Count(1), 2)
+	assert.Equal(t, v.AddCount("the", 3), int32(1))
+	assert.Equal(t, v.Count(1), 5)
+	assert.Equal(t, v.Word(2), "ёжик")
+	assert.Equal(t, v.Word(3), "")
+
+	id, ok := v.ID("missing")
+	assert.False(t, ok)
+	assert.Equal(t, id, int32(0))
+
+	v.Freeze()
+	assert.True(t, v.IsFrozen())
+	assert.Equal(t, v.Add("missing"), int32(0))
+	assert.Equal(t, v.Len(), 3)
+	v.Unfreeze()
+	assert.Equal(t, v.Add("missing"), int32(3))
+
+	noUnk := NewVocabulary("")
+	id, ok = noUnk.ID("the")
+	assert.False(t, ok)
+	assert.Equal(t, id, int32(-1))
+	noUnk.Freeze()
+	assert.Equal(t, noUnk.Add("the"), int32(-1))
+}
+
+func TestVocabularyPrune(t *testing.T) {
+	v := NewVocabulary("-UNK-")
+	for _, word := range []string{"a", "b", "a", "c", "c", "d", "c"} {
+		v.Add(word)
+	}
+	mapping := v.Prune(2)
+	assert.Equal(t, mapping, []int32{0, 1, 0, 2, 0})
+	assert.Equal(t, v.Words(), []string{"-UNK-", "a", "c"})
+	id, ok := v.ID("c")
+	assert.True(t, ok)
+	assert.Equal(t, id, int32(2))
+	assert.Equal(t, v.Count(2), 3)
+	id, _ = v.ID("b")
+	assert.Equal(t, id, int32(0))
+}
+
+func TestVocabularyFilter(t *testing.T) {
+	v := NewVocabulary("")
+	for _, word := range []string{"a", "b", "c", "b"} {
+		v.Add(word)
+	}
+	mapping := v.Filter(func(id int32, word string, count int) bool {
+		return word != "a"
+	})
+	assert.Equal(t, mapping, []int32{-1, 0, 1})
+	assert.Equal(t, v.Words(), []string{"b", "c"})
+	assert.Equal(t, v.Count(0), 2)
+}
+
+func TestVocabularyClone(t *testing.T) {
+	v := NewVocabulary("-UNK-")
+	v.Add("a")
+	clone := v.Clone()
+	assert.Equal(t, clone.Add("b"), int32(2))
+	assert.Equal(t, clone.Add("a"), int32(1))
+	assert.Equal(t, clone.Count(1), 2)
+	assert.Equal(t, v.Words(), []string{"-UNK-", "a"})
+	assert.Equal(t, v.Count(1), 1)
+}
+
+func TestVocabularyConcurrentPrune(t *testing.T) {
+	v := NewVocabulary("-UNK-")
+	for i := 0; i < 100; i++ {
+		v.Add(strconv.Itoa(i % 10))
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			assert.Equal(t, v.UnknownID(), int32(0))
+		}
+	}()
+	v.Prune(5)
+	<-done
+}
+
+func TestVocabularySaveLoad(t *testing.T) {
+	v := NewVocabulary("")
+	v.Add("NN")
+	v.Add("VB")
+	v.Add("NN")
+	v.Freeze()
+
+	var buf bytes.Buffer
+	assert.NoError(t, v.Save(&buf))
+	loaded, err := LoadVocabulary(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, loaded.Words(), []string{"NN", "VB"})
+	assert.Equal(t, loaded.Count(0), 2)
+	assert.True(t, loaded.IsFrozen())
+	assert.Equal(t, loaded.UnknownID(), int32(-1))
+	id, ok := loaded.ID("VB")
+	assert.True(t, ok)
+	assert.Equal(t, id, int32(1))
+
+	_, err = LoadVocabulary(bytes.NewReader([]byte("garbage")))
+	assert.Error(t, err)
+}
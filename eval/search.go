@@ -0,0 +1,268 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"github.com/korobool/nlp4go/pos"
+	"io"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"text/tabwriter"
+)
+
+// Hyperparameters of PerceptronTagger training
+type TaggerParams struct {
+	Rounds             int
+	FrequencyThreshold int
+	AmbiguityThreshold float64
+	FeatureTemplate    string
+}
+
+// Default values of hyperparameters: ones of PerceptronTagger and 5 rounds
+var defaultParams = TaggerParams{
+	Rounds:             5,
+	FrequencyThreshold: 20,
+	AmbiguityThreshold: 0.97,
+	FeatureTemplate:    pos.DefaultFeatureTemplate,
+}
+
+// Returns parameters with zero fields replaced by default values
+func (p TaggerParams) withDefaults() TaggerParams {
+	if p.Rounds == 0 {
+		p.Rounds = defaultParams.Rounds
+	}
+	if p.FrequencyThreshold == 0 {
+		p.FrequencyThreshold = defaultParams.FrequencyThreshold
+	}
+	if p.AmbiguityThreshold == 0 {
+		p.AmbiguityThreshold = defaultParams.AmbiguityThreshold
+	}
+	if p.FeatureTemplate == "" {
+		p.FeatureTemplate = defaultParams.FeatureTemplate
+	}
+	return p
+}
+
+func (p TaggerParams) String() string {
+	return fmt.Sprintf("rounds=%d frequency=%d ambiguity=%g template=%s",
+		p.Rounds, p.FrequencyThreshold, p.AmbiguityThreshold, p.FeatureTemplate)
+}
+
+// Values of hyperparameters to search over, empty lists mean
+// default values of PerceptronTagger (and 5 rounds)
+type ParamGrid struct {
+	Rounds             []int
+	FrequencyThreshold []int
+	AmbiguityThreshold []float64
+	FeatureTemplate    []string
+}
+
+// Grid returns all combinations of values (grid search)
+func (g ParamGrid) Grid() []TaggerParams {
+	rounds, frequencies, ambiguities, templates := g.values()
+	var params []TaggerParams
+	for _, r := range rounds {
+		for _, f := range frequencies {
+			for _, a := range ambiguities {
+				for _, t := range templates {
+					params = append(params, TaggerParams{r, f, a, t})
+				}
+			}
+		}
+	}
+	return params
+}
+
+// Random returns `n` distinct random combinations of values (random
+// search), all combinations if there are fewer than `n` of them
+func (g ParamGrid) Random(n int, seed int64) []TaggerParams {
+	grid := g.Grid()
+	rnd := rand.New(rand.NewSource(seed))
+	rnd.Shuffle(len(grid), func(i, j int) { grid[i], grid[j] = grid[j], grid[i] })
+	if n < len(grid) {
+		grid = grid[:n]
+	}
+	return grid
+}
+
+func (g ParamGrid) values() ([]int, []int, []float64, []string) {
+	rounds, frequencies, ambiguities, templates := g.Rounds, g.FrequencyThreshold, g.AmbiguityThreshold, g.FeatureTemplate
+	if len(rounds) == 0 {
+		rounds = []int{defaultParams.Rounds}
+	}
+	if len(frequencies) == 0 {
+		frequencies = []int{defaultParams.FrequencyThreshold}
+	}
+	if len(ambiguities) == 0 {
+		ambiguities = []float64{defaultParams.AmbiguityThreshold}
+	}
+	if len(templates) == 0 {
+		templates = []string{defaultParams.FeatureTemplate}
+	}
+	return rounds, frequencies, ambiguities, templates
+}
+
+// Options of hyperparameter search
+type SearchOptions struct {
+	// Count of folds of cross-validation, 5 if it's zero
+	Folds int
+	// If it's set, models are trained on all sentences and evaluated
+	// on Dev instead of cross-validation
+	Dev []pos.WordsTags
+	// Count of models trained in parallel, count of CPUs if it's zero
+	Parallel int
+	// Seed of assignment of sentences to folds
+	Seed int64
+}
+
+// Accuracy of tagger trained with parameters
+type SearchResult struct {
+	Params TaggerParams
+	// Accuracy of every fold (or of dev set)
+	Scores []float64
+	Mean   float64
+	StdDev float64
+}
+
+// TrainTagger trains PerceptronTagger with parameters, zero fields are
+// default values like in ParamGrid. Sentences aren't modified
+func TrainTagger(sentences []pos.WordsTags, params TaggerParams) (*pos.PerceptronTagger, error) {
+	params = params.withDefaults()
+	tagger, err := pos.NewPerceptronTagger(pos.TaggerConfig{})
+	if err != nil {
+		return nil, err
+	}
+	tagger.FrequencyThreshold = params.FrequencyThreshold
+	tagger.AmbiguityThreshold = params.AmbiguityThreshold
+	tagger.FeatureTemplate = params.FeatureTemplate
+	if err := tagger.Train(sentences, params.Rounds, nil); err != nil {
+		return nil, err
+	}
+	return tagger, nil
+}
+
+// Splits sentences into train and test parts of every fold
+func splitFolds(sentences []pos.WordsTags, folds int, seed int64) (train, test [][]pos.WordsTags) {
+	order := rand.New(rand.NewSource(seed)).Perm(len(sentences))
+	train = make([][]pos.WordsTags, folds)
+	test = make([][]pos.WordsTags, folds)
+	for i, idx := range order {
+		for fold := 0; fold < folds; fold++ {
+			if i%folds == fold {
+				test[fold] = append(test[fold], sentences[idx])
+			} else {
+				train[fold] = append(train[fold], sentences[idx])
+			}
+		}
+	}
+	return train, test
+}
+
+// Search evaluates every setting of parameters by k-fold cross-validation
+// (or on dev set), returns results sorted by mean accuracy
+func Search(sentences []pos.WordsTags, settings []TaggerParams, opts SearchOptions) ([]SearchResult, error) {
+	folds := opts.Folds
+	if folds == 0 {
+		folds = 5
+	}
+	var train, test [][]pos.WordsTags
+	if opts.Dev != nil {
+		train, test = [][]pos.WordsTags{sentences}, [][]pos.WordsTags{opts.Dev}
+	} else {
+		if folds < 2 || folds > len(sentences) {
+			return nil, fmt.Errorf("can't split %d sentences into %d folds", len(sentences), folds)
+		}
+		train, test = splitFolds(sentences, folds, opts.Seed)
+	}
+	if len(settings) == 0 {
+		return nil, errors.New("no parameters to search")
+	}
+	parallel := opts.Parallel
+	if parallel <= 0 {
+		parallel = runtime.NumCPU()
+	}
+
+	results := make([]SearchResult, len(settings))
+	for i, params := range settings {
+		results[i] = SearchResult{Params: params, Scores: make([]float64, len(train))}
+	}
+	type job struct{ setting, fold int }
+	jobs := make(chan job)
+	errs := make(chan error, len(settings)*len(train))
+	var wg sync.WaitGroup
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				tagger, err := TrainTagger(train[j.fold], settings[j.setting])
+				if err != nil {
+					errs <- err
+					continue
+				}
+				report, err := EvaluateTagger(tagger, test[j.fold], Options{})
+				if err != nil {
+					errs <- err
+					continue
+				}
+				// every job writes its own element
+				results[j.setting].Scores[j.fold] = report.Overall.Accuracy
+			}
+		}()
+	}
+	for s := range settings {
+		for fold := range train {
+			jobs <- job{s, fold}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		results[i].Mean, results[i].StdDev = meanStdDev(results[i].Scores)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Mean > results[j].Mean })
+	return results, nil
+}
+
+// CrossValidate evaluates one setting of parameters by k-fold cross-validation
+func CrossValidate(sentences []pos.WordsTags, params TaggerParams, opts SearchOptions) (SearchResult, error) {
+	results, err := Search(sentences, []TaggerParams{params}, opts)
+	if err != nil {
+		return SearchResult{}, err
+	}
+	return results[0], nil
+}
+
+// Returns mean and sample standard deviation
+func meanStdDev(values []float64) (float64, float64) {
+	var mean, variance float64
+	for _, v := range values {
+		mean += v / float64(len(values))
+	}
+	if len(values) < 2 {
+		return mean, 0
+	}
+	for _, v := range values {
+		variance += (v - mean) * (v - mean) / float64(len(values)-1)
+	}
+	return mean, math.Sqrt(variance)
+}
+
+// WriteSearchResults writes results of Search as a table
+func WriteSearchResults(w io.Writer, results []SearchResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "rounds\tfrequency\tambiguity\ttemplate\tmean\tstddev\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%d\t%d\t%g\t%s\t%.4f\t%.4f\t\n", r.Params.Rounds, r.Params.FrequencyThreshold,
+			r.Params.AmbiguityThreshold, r.Params.FeatureTemplate, r.Mean, r.StdDev)
+	}
+	return tw.Flush()
+}
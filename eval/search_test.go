@@ -0,0 +1,117 @@
+package eval
+
+import (
+	"bytes"
+	"github.com/korobool/nlp4go/pos"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// Random sentences of patterns "DT NN VBZ RB ." and "PRP VBP DT NN ."
+func makeTaggedSentences(count int, seed int64) []pos.WordsTags {
+	rnd := rand.New(rand.NewSource(seed))
+	words := map[string][]string{
+		"DT":  {"the", "a", "this"},
+		"NN":  {"dog", "cat", "fish", "walk", "run"},
+		"VBZ": {"runs", "walks", "sleeps", "fishes"},
+		"VBP": {"walk", "run", "fish", "like"},
+		"RB":  {"fast", "slowly", "often"},
+		"PRP": {"we", "they", "you"},
+		".":   {"."},
+	}
+	patterns := [][]string{
+		{"DT", "NN", "VBZ", "RB", "."},
+		{"PRP", "VBP", "DT", "NN", "."},
+	}
+	sentences := make([]pos.WordsTags, count)
+	for i := range sentences {
+		pattern := patterns[rnd.Intn(len(patterns))]
+		for _, tag := range pattern {
+			sentences[i].Words = append(sentences[i].Words, words[tag][rnd.Intn(len(words[tag]))])
+			sentences[i].Tags = append(sentences[i].Tags, tag)
+		}
+	}
+	return sentences
+}
+
+func TestParamGrid(t *testing.T) {
+	grid := ParamGrid{
+		Rounds:          []int{1, 3},
+		FeatureTemplate: []string{pos.FeatureTemplateV1, pos.FeatureTemplateV2},
+	}
+	params := grid.Grid()
+	if len(params) != 4 || params[0].FrequencyThreshold != 20 || params[0].AmbiguityThreshold != 0.97 {
+		t.Fatalf("wrong grid: %v", params)
+	}
+	random := grid.Random(3, 1)
+	seen := map[TaggerParams]bool{}
+	for _, p := range random {
+		seen[p] = true
+	}
+	if len(random) != 3 || len(seen) != 3 || len(grid.Random(10, 1)) != 4 {
+		t.Fatalf("wrong random search: %v", random)
+	}
+}
+
+func TestTrainTaggerDefaults(t *testing.T) {
+	tagger, err := TrainTagger(makeTaggedSentences(20, 1), TaggerParams{Rounds: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tagger.FrequencyThreshold != 20 || tagger.AmbiguityThreshold != 0.97 || tagger.FeatureTemplate != pos.DefaultFeatureTemplate {
+		t.Fatalf("wrong default parameters: %d %g %q", tagger.FrequencyThreshold, tagger.AmbiguityThreshold, tagger.FeatureTemplate)
+	}
+	var buf bytes.Buffer
+	if err := tagger.WriteModel(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := tagger.ReadModel(&buf); err != nil {
+		t.Fatalf("can't read trained model: %v", err)
+	}
+	if _, err := TrainTagger(makeTaggedSentences(20, 1), TaggerParams{FeatureTemplate: "v9"}); err == nil {
+		t.Fatal("expected error for unknown features")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	sentences := makeTaggedSentences(100, 1)
+	settings := ParamGrid{
+		Rounds:             []int{1, 5},
+		FrequencyThreshold: []int{1000},
+	}.Grid()
+
+	results, err := Search(sentences, settings, SearchOptions{Folds: 4, Parallel: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || len(results[0].Scores) != 4 || results[0].Mean < results[1].Mean {
+		t.Fatalf("wrong results: %+v", results)
+	}
+	for _, r := range results {
+		if r.Mean < 0.7 || r.StdDev < 0 || r.StdDev > 0.2 {
+			t.Fatalf("wrong accuracy of %s: %f ± %f", r.Params, r.Mean, r.StdDev)
+		}
+	}
+
+	dev := makeTaggedSentences(20, 2)
+	result, err := CrossValidate(sentences, settings[1], SearchOptions{Dev: dev})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Scores) != 1 || result.StdDev != 0 || result.Mean < 0.7 {
+		t.Fatalf("wrong dev result: %+v", result)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSearchResults(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 {
+		t.Fatalf("wrong table:\n%s", buf.String())
+	}
+
+	if _, err := Search(sentences[:3], settings, SearchOptions{Folds: 5}); err == nil {
+		t.Fatal("expected error for too few sentences")
+	}
+}
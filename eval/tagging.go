@@ -0,0 +1,294 @@
+// Package eval evaluates taggers against gold annotated sentences
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/korobool/nlp4go/pos"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Tagger of already tokenized sentences, it's implemented by
+// PerceptronTagger, HMMTagger and CRFTagger
+type Tagger interface {
+	TagWords(words []string) []string
+}
+
+// Accuracy of group of tokens
+type Accuracy struct {
+	Tokens   int     `json:"tokens"`
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+// Precision, recall and F1 of tag
+type TagScores struct {
+	Tag       string  `json:"tag"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	// Count of gold tokens with tag
+	Support int `json:"support"`
+}
+
+// Confusion matrix: Counts[gold][predicted] indexed as Tags
+type ConfusionMatrix struct {
+	Tags   []string `json:"tags"`
+	Counts [][]int  `json:"counts"`
+}
+
+// Word that is tagged wrong most often
+type WordErrors struct {
+	Word   string `json:"word"`
+	Errors int    `json:"errors"`
+	Total  int    `json:"total"`
+	// "gold -> predicted" -> count
+	Confusions map[string]int `json:"confusions"`
+}
+
+// Report of tagging evaluation
+type Report struct {
+	Overall Accuracy `json:"overall"`
+	// Tokens of words seen (or not) in training corpus
+	Known   Accuracy    `json:"known"`
+	Unknown Accuracy    `json:"unknown"`
+	Tags    []TagScores `json:"tags"`
+	// Unweighted mean of F1 of tags
+	MacroF1   float64         `json:"macro_f1"`
+	Confusion ConfusionMatrix `json:"confusion"`
+	MisTagged []WordErrors    `json:"mis_tagged"`
+}
+
+// Options of evaluation
+type Options struct {
+	// Words of training corpus, see KnownWords. If it's nil,
+	// all words are known
+	Known map[string]struct{}
+	// Count of the most frequently mis-tagged words in report,
+	// 20 if it's zero
+	TopErrors int
+}
+
+// KnownWords returns set of words of sentences
+func KnownWords(sentences []pos.WordsTags) map[string]struct{} {
+	words := make(map[string]struct{})
+	for _, sent := range sentences {
+		for _, word := range sent.Words {
+			words[word] = struct{}{}
+		}
+	}
+	return words
+}
+
+// TagSentences tags words of gold sentences by tagger
+func TagSentences(tagger Tagger, gold []pos.WordsTags) []pos.WordsTags {
+	predicted := make([]pos.WordsTags, len(gold))
+	for i, sent := range gold {
+		predicted[i] = pos.WordsTags{Words: sent.Words, Tags: tagger.TagWords(sent.Words)}
+	}
+	return predicted
+}
+
+// EvaluateTagger tags gold sentences by tagger and evaluates tags
+func EvaluateTagger(tagger Tagger, gold []pos.WordsTags, opts Options) (*Report, error) {
+	return Evaluate(gold, TagSentences(tagger, gold), opts)
+}
+
+// Evaluate compares predicted tags with gold ones, sentences must
+// have the same words
+func Evaluate(gold, predicted []pos.WordsTags, opts Options) (*Report, error) {
+	if len(gold) != len(predicted) {
+		return nil, fmt.Errorf("%d gold sentences and %d predicted ones", len(gold), len(predicted))
+	}
+	topErrors := opts.TopErrors
+	if topErrors == 0 {
+		topErrors = 20
+	}
+
+	report := &Report{}
+	tagIDs := make(map[string]int)
+	tagID := func(tag string) int {
+		id, ok := tagIDs[tag]
+		if !ok {
+			id = len(tagIDs)
+			tagIDs[tag] = id
+		}
+		return id
+	}
+	type pair struct{ gold, predicted int }
+	confusions := make(map[pair]int)
+	words := make(map[string]*WordErrors)
+
+	for i, goldSent := range gold {
+		predSent := predicted[i]
+		if len(goldSent.Words) != len(goldSent.Tags) || len(predSent.Tags) != len(goldSent.Tags) {
+			return nil, fmt.Errorf("sentence %d: %d words, %d gold tags and %d predicted ones",
+				i, len(goldSent.Words), len(goldSent.Tags), len(predSent.Tags))
+		}
+		if len(predSent.Words) != len(goldSent.Words) {
+			return nil, fmt.Errorf("sentence %d: %d gold words and %d predicted ones", i, len(goldSent.Words), len(predSent.Words))
+		}
+		for j, word := range predSent.Words {
+			if word != goldSent.Words[j] {
+				return nil, fmt.Errorf("sentence %d: predicted word %q differs from gold %q", i, word, goldSent.Words[j])
+			}
+		}
+		for j, word := range goldSent.Words {
+			goldTag, predTag := goldSent.Tags[j], predSent.Tags[j]
+			correct := goldTag == predTag
+			group := &report.Known
+			if _, ok := opts.Known[word]; opts.Known != nil && !ok {
+				group = &report.Unknown
+			}
+			for _, acc := range []*Accuracy{&report.Overall, group} {
+				acc.Tokens++
+				if correct {
+					acc.Correct++
+				}
+			}
+			confusions[pair{tagID(goldTag), tagID(predTag)}]++
+
+			stats, ok := words[word]
+			if !ok {
+				stats = &WordErrors{Word: word, Confusions: make(map[string]int)}
+				words[word] = stats
+			}
+			stats.Total++
+			if !correct {
+				stats.Errors++
+				stats.Confusions[goldTag+" -> "+predTag]++
+			}
+		}
+	}
+	for _, acc := range []*Accuracy{&report.Overall, &report.Known, &report.Unknown} {
+		acc.Accuracy = ratio(acc.Correct, acc.Tokens)
+	}
+
+	tags := make([]string, 0, len(tagIDs))
+	for tag := range tagIDs {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	report.Confusion = ConfusionMatrix{Tags: tags, Counts: make([][]int, len(tags))}
+	index := make(map[int]int, len(tags))
+	for i, tag := range tags {
+		index[tagIDs[tag]] = i
+		report.Confusion.Counts[i] = make([]int, len(tags))
+	}
+	for p, count := range confusions {
+		report.Confusion.Counts[index[p.gold]][index[p.predicted]] = count
+	}
+
+	for i, tag := range tags {
+		var goldCount, predCount int
+		for j := range tags {
+			goldCount += report.Confusion.Counts[i][j]
+			predCount += report.Confusion.Counts[j][i]
+		}
+		correct := report.Confusion.Counts[i][i]
+		scores := TagScores{
+			Tag:       tag,
+			Precision: ratio(correct, predCount),
+			Recall:    ratio(correct, goldCount),
+			Support:   goldCount,
+		}
+		if scores.Precision+scores.Recall > 0 {
+			scores.F1 = 2 * scores.Precision * scores.Recall / (scores.Precision + scores.Recall)
+		}
+		report.Tags = append(report.Tags, scores)
+		report.MacroF1 += scores.F1 / float64(len(tags))
+	}
+
+	for _, stats := range words {
+		if stats.Errors > 0 {
+			report.MisTagged = append(report.MisTagged, *stats)
+		}
+	}
+	sort.Slice(report.MisTagged, func(i, j int) bool {
+		a, b := report.MisTagged[i], report.MisTagged[j]
+		if a.Errors != b.Errors {
+			return a.Errors > b.Errors
+		}
+		return a.Word < b.Word
+	})
+	if topErrors > 0 && len(report.MisTagged) > topErrors {
+		report.MisTagged = report.MisTagged[:topErrors]
+	}
+	return report, nil
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+// WriteJSON writes report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteText writes report as human readable tables
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tw, "\ttokens\tcorrect\taccuracy\t")
+	for _, line := range []struct {
+		name string
+		acc  Accuracy
+	}{{"overall", r.Overall}, {"known", r.Known}, {"unknown", r.Unknown}} {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.4f\t\n", line.name, line.acc.Tokens, line.acc.Correct, line.acc.Accuracy)
+	}
+	fmt.Fprintln(tw, "\t\t\t\t")
+
+	fmt.Fprintln(tw, "tag\tprecision\trecall\tf1\tsupport\t")
+	for _, s := range r.Tags {
+		fmt.Fprintf(tw, "%s\t%.4f\t%.4f\t%.4f\t%d\t\n", s.Tag, s.Precision, s.Recall, s.F1, s.Support)
+	}
+	fmt.Fprintf(tw, "macro F1\t\t\t%.4f\t\t\n", r.MacroF1)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// rows are gold tags, columns are predicted ones
+	fmt.Fprintln(w, "\nconfusion matrix (gold \\ predicted)")
+	tw = tabwriter.NewWriter(w, 0, 0, 1, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "\t%s\t\n", strings.Join(r.Confusion.Tags, "\t"))
+	for i, tag := range r.Confusion.Tags {
+		counts := make([]string, len(r.Confusion.Counts[i]))
+		for j, count := range r.Confusion.Counts[i] {
+			counts[j] = fmt.Sprint(count)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t\n", tag, strings.Join(counts, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "\nmost frequently mis-tagged words")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, word := range r.MisTagged {
+		confusions := make([]string, 0, len(word.Confusions))
+		for confusion := range word.Confusions {
+			confusions = append(confusions, confusion)
+		}
+		sort.Slice(confusions, func(i, j int) bool {
+			a, b := word.Confusions[confusions[i]], word.Confusions[confusions[j]]
+			if a != b {
+				return a > b
+			}
+			return confusions[i] < confusions[j]
+		})
+		for i, confusion := range confusions {
+			confusions[i] = fmt.Sprintf("%s (%d)", confusion, word.Confusions[confusion])
+		}
+		fmt.Fprintf(tw, "%s\t%d/%d\t%s\n", word.Word, word.Errors, word.Total, strings.Join(confusions, ", "))
+	}
+	return tw.Flush()
+}
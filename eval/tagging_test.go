@@ -0,0 +1,107 @@
+package eval
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/korobool/nlp4go/pos"
+	"math"
+	"strings"
+	"testing"
+)
+
+// Tags "fish" as NN and everything else as DT
+type dummyTagger struct{}
+
+func (dummyTagger) TagWords(words []string) []string {
+	tags := make([]string, len(words))
+	for i, word := range words {
+		tags[i] = "DT"
+		if word == "fish" {
+			tags[i] = "NN"
+		}
+	}
+	return tags
+}
+
+func TestEvaluate(t *testing.T) {
+	gold := []pos.WordsTags{
+		{Words: []string{"the", "fish"}, Tags: []string{"DT", "NN"}},
+		{Words: []string{"they", "fish", "the", "cod"}, Tags: []string{"PRP", "VBP", "DT", "NN"}},
+	}
+	known := KnownWords(gold[:1])
+	report, err := EvaluateTagger(dummyTagger{}, gold, Options{Known: known})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Overall != (Accuracy{6, 3, 0.5}) {
+		t.Fatalf("wrong overall accuracy: %+v", report.Overall)
+	}
+	// "the", "fish", "fish", "the" are known
+	if report.Known != (Accuracy{4, 3, 0.75}) || report.Unknown != (Accuracy{2, 0, 0}) {
+		t.Fatalf("wrong known/unknown accuracy: %+v %+v", report.Known, report.Unknown)
+	}
+
+	scores := map[string]TagScores{}
+	for _, s := range report.Tags {
+		scores[s.Tag] = s
+	}
+	// DT: predicted 4 times, 2 correct; NN: predicted twice, 1 correct of 2 gold
+	if dt := scores["DT"]; dt.Precision != 0.5 || dt.Recall != 1 || dt.Support != 2 {
+		t.Fatalf("wrong scores of DT: %+v", dt)
+	}
+	if nn := scores["NN"]; nn.Precision != 0.5 || nn.Recall != 0.5 || nn.F1 != 0.5 {
+		t.Fatalf("wrong scores of NN: %+v", nn)
+	}
+	if f1 := (2.0/3 + 0.5) / 4; math.Abs(report.MacroF1-f1) > 1e-9 {
+		t.Fatalf("wrong macro F1: %f", report.MacroF1)
+	}
+
+	if tags := strings.Join(report.Confusion.Tags, " "); tags != "DT NN PRP VBP" {
+		t.Fatalf("wrong tags of confusion matrix: %s", tags)
+	}
+	// gold NN predicted as DT ("cod"), gold VBP predicted as NN ("fish")
+	if report.Confusion.Counts[1][0] != 1 || report.Confusion.Counts[3][1] != 1 || report.Confusion.Counts[0][0] != 2 {
+		t.Fatalf("wrong confusion matrix: %v", report.Confusion.Counts)
+	}
+
+	if len(report.MisTagged) != 3 || report.MisTagged[0].Word != "cod" ||
+		report.MisTagged[1].Word != "fish" || report.MisTagged[1].Confusions["VBP -> NN"] != 1 || report.MisTagged[1].Total != 2 {
+		t.Fatalf("wrong mis-tagged words: %+v", report.MisTagged)
+	}
+
+	var text bytes.Buffer
+	if err := report.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"overall", "macro F1", "confusion matrix", "VBP -> NN (1)"} {
+		if !strings.Contains(text.String(), s) {
+			t.Fatalf("text report has no %q:\n%s", s, text.String())
+		}
+	}
+	// accuracy columns are aligned under their header
+	lines := strings.Split(text.String(), "\n")
+	if strings.Index(lines[0], "accuracy")+len("accuracy") != strings.Index(lines[1], "0.5000")+len("0.5000") {
+		t.Fatalf("misaligned header of text report:\n%s", text.String())
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Overall != report.Overall || len(decoded.Tags) != len(report.Tags) {
+		t.Fatalf("wrong JSON report: %s", buf.String())
+	}
+
+	if _, err := Evaluate(gold, gold[:1], Options{}); err == nil {
+		t.Fatal("expected error for different count of sentences")
+	}
+	other := []pos.WordsTags{gold[0], {Words: []string{"they", "fish", "a", "cod"}, Tags: gold[1].Tags}}
+	if _, err := Evaluate(gold, other, Options{}); err == nil {
+		t.Fatal("expected error for different words")
+	}
+}
@@ -1,107 +1,467 @@
 package ml
 
-type FeatureClass struct {
-	f string
-	c string
-}
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"github.com/korobool/nlp4go/core"
+	"io"
+	"math/rand"
+)
 
+// AveragedPerceptron is a multiclass perceptron with averaged weights.
+//
+// Features and classes are interned into integer IDs by `Features` and
+// `Classes` vocabularies, weights of every feature are stored as dense
+// vector indexed by class ID. Vocabularies can be shared with other
+// components (e.g. a tagger can use Classes for its tagset)
 type AveragedPerceptron struct {
-	i       int
-	totals  map[FeatureClass]float64
-	tstamps map[FeatureClass]int
-	Weights map[string]map[string]float64
+	Features *core.Vocabulary
+	Classes  *core.Vocabulary
+	TieBreak TieBreak
+	// Count of epochs of Train
+	Rounds int
+	// Precision of saved weights, see Quantize
+	Precision Precision
+	i         int
+	// weights[feature][class], vectors are allocated on first update
+	// of the feature and may be shorter than count of classes
+	weights [][]float64
+	totals  [][]float64
+	tstamps [][]int
+}
+
+// Kind of AveragedPerceptron models in model file header
+const AveragedPerceptronKind = "averaged_perceptron"
+
+// Rule of choosing between classes with equal scores
+type TieBreak int
+
+const (
+	// Lexicographically smaller label wins
+	TieBreakSmallest TieBreak = iota
+	// Lexicographically greater label wins (as in NLTK)
+	TieBreakGreatest
+)
+
+// Reports whether class `a` wins class `b` with equal score
+func (tb TieBreak) wins(a, b string) bool {
+	if tb == TieBreakGreatest {
+		return a > b
+	}
+	return a < b
+}
+
+// Serializable state of AveragedPerceptron
+type averagedPerceptronDump struct {
+	Features *core.Vocabulary
+	Classes  *core.Vocabulary
+	Weights  [][]float64
+	TieBreak TieBreak
+	// Weights of lower precision are stored instead of Weights
+	Precision Precision
+	Weights32 [][]float32
+	// int8 weights and scales of features
+	Weights8 [][]byte
+	Scales   []float32
 }
 
 func NewAveragedPerceptron() *AveragedPerceptron {
 	return &AveragedPerceptron{
-		totals:  make(map[FeatureClass]float64),
-		tstamps: make(map[FeatureClass]int),
-		Weights: make(map[string]map[string]float64),
+		Features: core.NewVocabulary(""),
+		Classes:  core.NewVocabulary(""),
+		Rounds:   5,
+	}
+}
+
+// FeatureIDs converts feature names to IDs. If `add` is true, missing
+// features are added to vocabulary (and their frequencies are counted),
+// otherwise they get ID -1 and are ignored by the model
+func (ap *AveragedPerceptron) FeatureIDs(features []string, add bool) []int32 {
+	ids := make([]int32, len(features))
+	for i, feat := range features {
+		if add {
+			ids[i] = ap.Features.Add(feat)
+		} else {
+			ids[i], _ = ap.Features.ID(feat)
+		}
+	}
+	return ids
+}
+
+// Returns ID of class label, adds it to Classes if `add` is true
+func (ap *AveragedPerceptron) ClassID(label string, add bool) int32 {
+	if add {
+		if id, ok := ap.Classes.ID(label); ok {
+			return id
+		}
+		return ap.Classes.Add(label)
 	}
+	id, _ := ap.Classes.ID(label)
+	return id
 }
 
-func (ap *AveragedPerceptron) Predict(features map[string]int) string {
+// Returns scores of all classes (indexed by class ID) for features
+func (ap *AveragedPerceptron) ScoresIDs(features []int32) []float64 {
+	scores := make([]float64, ap.Classes.Len())
+	ap.scoresInto(scores, features)
+	return scores
+}
 
-	scores := map[string]float64{}
+func (ap *AveragedPerceptron) scoresInto(scores []float64, features []int32) {
+	for _, feat := range features {
+		if feat < 0 || int(feat) >= len(ap.weights) {
+			continue
+		}
+		for class, weight := range ap.weights[feat] {
+			scores[class] += weight
+		}
+	}
+}
 
-	for feat, value := range features {
-		if _, ok := ap.Weights[feat]; !ok || value == 0 {
+// Returns scores of all classes for features with values
+func (ap *AveragedPerceptron) vectorScores(vec sparseVector) []float64 {
+	scores := make([]float64, ap.Classes.Len())
+	for i, feat := range vec.ids {
+		if int(feat) >= len(ap.weights) {
 			continue
 		}
-		for label, weight := range ap.Weights[feat] {
-			scores[label] += float64(value) * weight
+		for class, weight := range ap.weights[feat] {
+			scores[class] += vec.values[i] * weight
 		}
 	}
-	return ap.maxScore(scores)
+	return scores
 }
 
-func (ap *AveragedPerceptron) Update(truth, guess string, features map[string]int) {
+// Returns ID of class with max score, ties are resolved by TieBreak
+// Returns -1 if there are no classes
+func (ap *AveragedPerceptron) maxScore(scores []float64) int32 {
+	best := int32(-1)
+	for class, score := range scores {
+		switch {
+		case best < 0 || score > scores[best]:
+			best = int32(class)
+		case score == scores[best]:
+			if ap.TieBreak.wins(ap.Classes.Word(int32(class)), ap.Classes.Word(best)) {
+				best = int32(class)
+			}
+		}
+	}
+	return best
+}
 
+// PredictIDs returns ID of the best class for features
+func (ap *AveragedPerceptron) PredictIDs(features []int32) int32 {
+	return ap.maxScore(ap.ScoresIDs(features))
+}
+
+// Predict returns the best class label for features,
+// weights are multiplied by values of features
+func (ap *AveragedPerceptron) Predict(features Features) string {
+	return ap.Classes.Word(ap.maxScore(ap.vectorScores(encodeFeatures(ap.Features, features, false))))
+}
+
+// PredictProba returns softmax-normalized scores of classes
+func (ap *AveragedPerceptron) PredictProba(features Features) map[string]float64 {
+	return probaMap(ap.Classes, ap.vectorScores(encodeFeatures(ap.Features, features, false)))
+}
+
+// PredictFeatures returns the best class label for list of feature names
+func (ap *AveragedPerceptron) PredictFeatures(features []string) string {
+	return ap.Classes.Word(ap.PredictIDs(ap.FeatureIDs(features, false)))
+}
+
+// PredictScoresIDs returns scores of all classes for features
+func (ap *AveragedPerceptron) PredictScoresIDs(features []int32) *Prediction {
+	return newPrediction(ap.ScoresIDs(features), ap.Classes.Word, ap.TieBreak, 0)
+}
+
+// PredictScores returns scores of all classes for list of feature names
+func (ap *AveragedPerceptron) PredictScores(features []string) *Prediction {
+	return ap.PredictScoresIDs(ap.FeatureIDs(features, false))
+}
+
+// PredictTopK returns `k` best classes for list of feature names,
+// probabilities and margin are computed over all classes
+func (ap *AveragedPerceptron) PredictTopK(features []string, k int) *Prediction {
+	return newPrediction(ap.ScoresIDs(ap.FeatureIDs(features, false)), ap.Classes.Word, ap.TieBreak, k)
+}
+
+// UpdateIDs moves weights of features towards `truth` class and away from
+// `guess` one if they differ. Features must be valid IDs of Features
+func (ap *AveragedPerceptron) UpdateIDs(truth, guess int32, features []int32) {
 	ap.i += 1
 	if truth == guess {
 		return
 	}
+	for _, feat := range features {
+		if feat < 0 {
+			continue
+		}
+		ap.updateFeature(truth, feat, 1.0)
+		if guess >= 0 {
+			ap.updateFeature(guess, feat, -1.0)
+		}
+	}
+}
 
-	var wt, wg float64
+// Update is UpdateIDs for class labels and features,
+// all features with non-zero values are updated by 1
+func (ap *AveragedPerceptron) Update(truth, guess string, features Features) {
+	if truth == guess {
+		ap.i += 1
+		return
+	}
+	vec := encodeFeatures(ap.Features, features, true)
+	ap.UpdateIDs(ap.ClassID(truth, true), ap.ClassID(guess, guess != ""), vec.ids)
+}
 
-	for f := range features {
-		if _, ok := ap.Weights[f]; !ok {
-			ap.Weights[f] = make(map[string]float64)
-		} else {
-			wt = ap.Weights[f][truth]
-			wg = ap.Weights[f][guess]
+// Train trains model on samples for Rounds epochs (5 if Rounds isn't set)
+// and averages weights. Samples are shuffled between epochs
+func (ap *AveragedPerceptron) Train(samples []Sample) error {
+	rounds := ap.Rounds
+	if rounds <= 0 {
+		rounds = 5
+	}
+	encoded := encodeSamples(ap.Features, ap.Classes, samples)
+	rnd := rand.New(rand.NewSource(1))
+	for it := 0; it < rounds; it++ {
+		for _, sample := range encoded {
+			guess := ap.maxScore(ap.vectorScores(sample.sparseVector))
+			ap.UpdateIDs(sample.class, guess, sample.ids)
 		}
-		ap.updateFeature(truth, f, wt, 1.0)
-		ap.updateFeature(guess, f, wg, -1.0)
+		rnd.Shuffle(len(encoded), func(i, j int) { encoded[i], encoded[j] = encoded[j], encoded[i] })
 	}
-	return
+	ap.AverageWeights()
+	return nil
 }
 
-func (ap *AveragedPerceptron) AverageWeights() {
+// UpdateFeatures is UpdateIDs for class labels and list of feature names
+// Features are added to vocabulary only on mistakes, so their counts are
+// numbers of updates they took part in
+func (ap *AveragedPerceptron) UpdateFeatures(truth, guess string, features []string) {
+	if truth == guess {
+		ap.i += 1
+		return
+	}
+	ap.UpdateIDs(ap.ClassID(truth, true), ap.ClassID(guess, guess != ""), ap.FeatureIDs(features, true))
+}
 
-	for feat, weights := range ap.Weights {
+// Decision of structured prediction: class chosen for features
+type Decision struct {
+	Class    string
+	Features []string
+}
 
-		newWeights := map[string]float64{}
+// UpdateStructured is the structured perceptron update of sequence of
+// decisions: weights of `truth` decisions are moved towards their classes
+// and weights of `guess` decisions away from theirs. Equal decisions at the
+// same positions are skipped. It counts as a single update for averaging
+func (ap *AveragedPerceptron) UpdateStructured(truth, guess []Decision) {
+	ap.i += 1
+	for j := range truth {
+		if j < len(guess) && sameDecision(truth[j], guess[j]) {
+			continue
+		}
+		class := ap.ClassID(truth[j].Class, true)
+		for _, feat := range ap.FeatureIDs(truth[j].Features, true) {
+			if feat >= 0 {
+				ap.updateFeature(class, feat, 1.0)
+			}
+		}
+	}
+	for j := range guess {
+		if j < len(truth) && sameDecision(truth[j], guess[j]) {
+			continue
+		}
+		class := ap.ClassID(guess[j].Class, true)
+		for _, feat := range ap.FeatureIDs(guess[j].Features, true) {
+			if feat >= 0 {
+				ap.updateFeature(class, feat, -1.0)
+			}
+		}
+	}
+}
 
-		for class, weight := range weights {
+func sameDecision(a, b Decision) bool {
+	if a.Class != b.Class || len(a.Features) != len(b.Features) {
+		return false
+	}
+	for i := range a.Features {
+		if a.Features[i] != b.Features[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Extends vectors of feature, so they can be indexed by class
+// Training state of averaged (or loaded) model is restarted from zero
+func (ap *AveragedPerceptron) grow(feature, class int32) {
+	for int(feature) >= len(ap.weights) {
+		ap.weights = append(ap.weights, nil)
+	}
+	for len(ap.totals) < len(ap.weights) {
+		ap.totals = append(ap.totals, nil)
+		ap.tstamps = append(ap.tstamps, nil)
+	}
+	size := int(class) + 1
+	if len(ap.weights[feature]) > size {
+		size = len(ap.weights[feature])
+	}
+	if need := size - len(ap.weights[feature]); need > 0 {
+		ap.weights[feature] = append(ap.weights[feature], make([]float64, need)...)
+	}
+	if need := size - len(ap.totals[feature]); need > 0 {
+		ap.totals[feature] = append(ap.totals[feature], make([]float64, need)...)
+		ap.tstamps[feature] = append(ap.tstamps[feature], make([]int, need)...)
+	}
+}
 
-			param := FeatureClass{feat, class}
-			total := ap.totals[param]
-			total += float64(ap.i-ap.tstamps[param]) * weight
+func (ap *AveragedPerceptron) updateFeature(class, feature int32, value float64) {
+	ap.grow(feature, class)
+	weight := ap.weights[feature][class]
+	ap.totals[feature][class] += float64(ap.i-ap.tstamps[feature][class]) * weight
+	ap.tstamps[feature][class] = ap.i
+	ap.weights[feature][class] = weight + value
+}
+
+// AverageWeights replaces weights by their averages over all updates.
+// Training state is released, so model can't be trained further
+func (ap *AveragedPerceptron) AverageWeights() {
+	if ap.i == 0 {
+		return
+	}
+	for feat, weights := range ap.weights {
+		var last int
+		for class, weight := range weights {
+			// weights of fine-tuned model that weren't updated
+			// have no training state
+			var total float64
+			var tstamp int
+			if feat < len(ap.totals) && class < len(ap.totals[feat]) {
+				total, tstamp = ap.totals[feat][class], ap.tstamps[feat][class]
+			}
+			total += float64(ap.i-tstamp) * weight
 
-			averaged := Round(total/float64(ap.i), 0.5, 3)
-			if averaged != 0 {
-				newWeights[class] = averaged
+			weights[class] = Round(total/float64(ap.i), 0.5, 3)
+			if weights[class] != 0 {
+				last = class + 1
 			}
 		}
-		ap.Weights[feat] = newWeights
+		if last == 0 {
+			ap.weights[feat] = nil
+		} else {
+			ap.weights[feat] = weights[:last:last]
+		}
 	}
-	return
+	ap.i = 0
+	ap.totals = nil
+	ap.tstamps = nil
 }
 
-func (ap *AveragedPerceptron) updateFeature(class, feature string, weight, value float64) {
-
-	param := FeatureClass{feature, class}
-	ap.totals[param] += float64(ap.i-ap.tstamps[param]) * weight
-	ap.tstamps[param] = ap.i
-	ap.Weights[feature][class] = weight + value
+// Averaged returns copy of model with averaged weights, model itself
+// keeps its training state, so training can be continued
+func (ap *AveragedPerceptron) Averaged() *AveragedPerceptron {
+	avg := &AveragedPerceptron{
+		Features:  ap.Features.Clone(),
+		Classes:   ap.Classes.Clone(),
+		TieBreak:  ap.TieBreak,
+		Rounds:    ap.Rounds,
+		Precision: ap.Precision,
+		i:         ap.i,
+		weights:   make([][]float64, len(ap.weights)),
+		// only read by averaging
+		totals:  ap.totals,
+		tstamps: ap.tstamps,
+	}
+	for feat, weights := range ap.weights {
+		avg.weights[feat] = append([]float64(nil), weights...)
+	}
+	avg.AverageWeights()
+	return avg
 }
 
-func (ap *AveragedPerceptron) maxScore(m map[string]float64) string {
-	var maxKey string
-	var maxVal float64
+// ImportWeights replaces model weights by ones from map feature -> class -> weight
+// (format of models saved by previous versions of PerceptronTagger)
+func (ap *AveragedPerceptron) ImportWeights(weights map[string]map[string]float64) {
+	ap.Features = core.NewVocabulary("")
+	ap.weights = make([][]float64, 0, len(weights))
+	ap.totals, ap.tstamps, ap.i = nil, nil, 0
 
-	for k, v := range m {
-		switch {
-		case v > maxVal:
-			maxKey = k
-			maxVal = v
-		case v == maxVal:
-			if k < maxKey {
-				maxKey = k
-				maxVal = v
+	for feat, classWeights := range weights {
+		id := ap.Features.Add(feat)
+		ap.weights = append(ap.weights, nil)
+		for label, weight := range classWeights {
+			class := ap.ClassID(label, true)
+			if need := int(class) + 1 - len(ap.weights[id]); need > 0 {
+				ap.weights[id] = append(ap.weights[id], make([]float64, need)...)
 			}
+			ap.weights[id][class] = weight
+		}
+	}
+}
+
+// ExportWeights returns non-zero weights as map feature -> class -> weight
+func (ap *AveragedPerceptron) ExportWeights() map[string]map[string]float64 {
+	weights := make(map[string]map[string]float64, len(ap.weights))
+	for feat, classWeights := range ap.weights {
+		m := make(map[string]float64)
+		for class, weight := range classWeights {
+			if weight != 0 {
+				m[ap.Classes.Word(int32(class))] = weight
+			}
+		}
+		if len(m) > 0 {
+			weights[ap.Features.Word(int32(feat))] = m
 		}
 	}
-	return maxKey
+	return weights
+}
+
+// GobEncode implements gob.GobEncoder interface, only weights are saved,
+// so model should be averaged before saving
+func (ap *AveragedPerceptron) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	dump := averagedPerceptronDump{Features: ap.Features, Classes: ap.Classes, TieBreak: ap.TieBreak}
+	ap.encodeWeights(&dump)
+	if err := gob.NewEncoder(&buf).Encode(dump); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder interface
+func (ap *AveragedPerceptron) GobDecode(data []byte) error {
+	var dump averagedPerceptronDump
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&dump); err != nil {
+		return err
+	}
+	if dump.Features == nil || dump.Classes == nil {
+		return errors.New("perceptron has no vocabularies")
+	}
+	if dump.Precision == PrecisionInt8 && len(dump.Scales) != len(dump.Weights8) {
+		return errors.New("perceptron has no scales of int8 weights")
+	}
+	weights := dump.decodeWeights()
+	for _, vector := range weights {
+		if len(vector) > dump.Classes.Len() {
+			return errors.New("perceptron weights don't match classes")
+		}
+	}
+	ap.Features, ap.Classes, ap.weights, ap.TieBreak = dump.Features, dump.Classes, weights, dump.TieBreak
+	ap.Precision = dump.Precision
+	ap.totals, ap.tstamps, ap.i = nil, nil, 0
+	return nil
+}
+
+// Save writes averaged model to `w` in model file format
+func (ap *AveragedPerceptron) Save(w io.Writer) error {
+	header := ModelHeader{Kind: AveragedPerceptronKind, Tagset: ap.Classes.Words()}
+	return WriteModel(w, header, ap)
+}
+
+// Load reads model written by Save
+func (ap *AveragedPerceptron) Load(r io.Reader) error {
+	return loadModel(r, AveragedPerceptronKind, ap)
 }
@@ -0,0 +1,255 @@
+package ml
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// mapAveragedPerceptron is the previous map-based implementation
+// kept as a baseline for benchmarks
+type mapAveragedPerceptron struct {
+	i       int
+	totals  map[[2]string]float64
+	tstamps map[[2]string]int
+	weights map[string]map[string]float64
+}
+
+func newMapAveragedPerceptron() *mapAveragedPerceptron {
+	return &mapAveragedPerceptron{
+		totals:  make(map[[2]string]float64),
+		tstamps: make(map[[2]string]int),
+		weights: make(map[string]map[string]float64),
+	}
+}
+
+func (ap *mapAveragedPerceptron) Predict(features []string) string {
+	scores := map[string]float64{}
+	for _, feat := range features {
+		for label, weight := range ap.weights[feat] {
+			scores[label] += weight
+		}
+	}
+	var maxKey string
+	var maxVal float64
+	for k, v := range scores {
+		if v > maxVal || (v == maxVal && k < maxKey) {
+			maxKey, maxVal = k, v
+		}
+	}
+	return maxKey
+}
+
+func (ap *mapAveragedPerceptron) Update(truth, guess string, features []string) {
+	ap.i += 1
+	if truth == guess {
+		return
+	}
+	for _, f := range features {
+		if _, ok := ap.weights[f]; !ok {
+			ap.weights[f] = make(map[string]float64)
+		}
+		ap.updateFeature(truth, f, 1.0)
+		ap.updateFeature(guess, f, -1.0)
+	}
+}
+
+func (ap *mapAveragedPerceptron) updateFeature(class, feature string, value float64) {
+	param := [2]string{feature, class}
+	weight := ap.weights[feature][class]
+	ap.totals[param] += float64(ap.i-ap.tstamps[param]) * weight
+	ap.tstamps[param] = ap.i
+	ap.weights[feature][class] = weight + value
+}
+
+func (ap *mapAveragedPerceptron) AverageWeights() {
+	for feat, weights := range ap.weights {
+		newWeights := map[string]float64{}
+		for class, weight := range weights {
+			param := [2]string{feat, class}
+			total := ap.totals[param] + float64(ap.i-ap.tstamps[param])*weight
+			if averaged := Round(total/float64(ap.i), 0.5, 3); averaged != 0 {
+				newWeights[class] = averaged
+			}
+		}
+		ap.weights[feat] = newWeights
+	}
+	ap.totals, ap.tstamps = nil, nil
+}
+
+type example struct {
+	features []string
+	class    string
+}
+
+// Generates tagger-like data: every example has 14 features, one of them
+// (the "word") mostly determines the class, others are noise
+func makeExamples(count, words, classes int, seed int64) []example {
+	rnd := rand.New(rand.NewSource(seed))
+	examples := make([]example, count)
+	for i := range examples {
+		word := rnd.Intn(words)
+		class := word % classes
+		if rnd.Intn(10) == 0 {
+			class = rnd.Intn(classes)
+		}
+		features := []string{"bias", fmt.Sprintf("i word %d", word)}
+		for j := 0; j < 12; j++ {
+			features = append(features, fmt.Sprintf("f%d %d", j, rnd.Intn(words)))
+		}
+		examples[i] = example{features, fmt.Sprintf("C%02d", class)}
+	}
+	return examples
+}
+
+func trainDense(examples []example, rounds int) *AveragedPerceptron {
+	ap := NewAveragedPerceptron()
+	for it := 0; it < rounds; it++ {
+		for _, ex := range examples {
+			ap.UpdateFeatures(ex.class, ap.PredictFeatures(ex.features), ex.features)
+		}
+	}
+	ap.AverageWeights()
+	return ap
+}
+
+func trainMap(examples []example, rounds int) *mapAveragedPerceptron {
+	ap := newMapAveragedPerceptron()
+	for it := 0; it < rounds; it++ {
+		for _, ex := range examples {
+			ap.Update(ex.class, ap.Predict(ex.features), ex.features)
+		}
+	}
+	ap.AverageWeights()
+	return ap
+}
+
+func accuracy(predict func([]string) string, examples []example) float64 {
+	correct := 0
+	for _, ex := range examples {
+		if predict(ex.features) == ex.class {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(examples))
+}
+
+func TestAveragedPerceptron(t *testing.T) {
+	train := makeExamples(5000, 200, 10, 1)
+	test := makeExamples(1000, 200, 10, 2)
+
+	ap := trainDense(train, 5)
+	baseline := trainMap(train, 5)
+	acc, mapAcc := accuracy(ap.PredictFeatures, test), accuracy(baseline.Predict, test)
+	if acc < 0.7 || acc < mapAcc-0.02 {
+		t.Fatalf("accuracy is too low: %.3f (map-based model: %.3f)", acc, mapAcc)
+	}
+
+	// map API gives the same predictions
+	for _, ex := range test[:100] {
+		features := Features{}
+		for _, feat := range ex.features {
+			features[feat] += 1
+		}
+		if ap.Predict(features) != ap.PredictFeatures(ex.features) {
+			t.Fatalf("Predict and PredictFeatures differ for %v", ex.features)
+		}
+	}
+}
+
+func TestAveragedPerceptronImportExport(t *testing.T) {
+	ap := trainDense(makeExamples(2000, 100, 5, 3), 3)
+	weights := ap.ExportWeights()
+
+	imported := NewAveragedPerceptron()
+	imported.ImportWeights(weights)
+	if !reflect.DeepEqual(imported.ExportWeights(), weights) {
+		t.Fatalf("weights changed after import")
+	}
+	for _, ex := range makeExamples(500, 100, 5, 4) {
+		if imported.PredictFeatures(ex.features) != ap.PredictFeatures(ex.features) {
+			t.Fatalf("imported model predicts differently for %v", ex.features)
+		}
+	}
+}
+
+func TestAveragedPerceptronUnknownFeatures(t *testing.T) {
+	ap := NewAveragedPerceptron()
+	ap.UpdateFeatures("A", "B", []string{"x"})
+	ap.UpdateFeatures("B", "A", []string{"y"})
+	ap.UpdateFeatures("A", "A", []string{"x"})
+	ap.AverageWeights()
+
+	if ap.PredictFeatures([]string{"y", "unknown"}) != "B" {
+		t.Fatalf("unknown features should be ignored")
+	}
+	// ties are resolved in favor of lexicographically smaller class
+	if ap.PredictFeatures([]string{"unknown"}) != "A" {
+		t.Fatalf("wrong tie resolution")
+	}
+}
+
+// Returns heap size occupied by model built by `build`
+func modelSize(build func() interface{}) (interface{}, uint64) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	model := build()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	return model, after.HeapAlloc - before.HeapAlloc
+}
+
+func benchmarkPredict(b *testing.B, build func() interface{}, predict func(interface{}, []string) string) {
+	examples := makeExamples(20000, 5000, 40, 5)
+	model, size := modelSize(build)
+
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		predict(model, examples[i%len(examples)].features)
+	}
+	b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "tokens/s")
+	b.ReportMetric(float64(size), "model-bytes")
+}
+
+func BenchmarkPredictDense(b *testing.B) {
+	train := makeExamples(20000, 5000, 40, 6)
+	benchmarkPredict(b,
+		func() interface{} { return trainDense(train, 3) },
+		func(model interface{}, features []string) string {
+			return model.(*AveragedPerceptron).PredictFeatures(features)
+		})
+}
+
+func BenchmarkPredictMap(b *testing.B) {
+	train := makeExamples(20000, 5000, 40, 6)
+	benchmarkPredict(b,
+		func() interface{} { return trainMap(train, 3) },
+		func(model interface{}, features []string) string {
+			return model.(*mapAveragedPerceptron).Predict(features)
+		})
+}
+
+func BenchmarkTrainDense(b *testing.B) {
+	train := makeExamples(20000, 5000, 40, 7)
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		trainDense(train, 1)
+	}
+	b.ReportMetric(float64(len(train)*b.N)/time.Since(start).Seconds(), "tokens/s")
+}
+
+func BenchmarkTrainMap(b *testing.B) {
+	train := makeExamples(20000, 5000, 40, 7)
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		trainMap(train, 1)
+	}
+	b.ReportMetric(float64(len(train)*b.N)/time.Since(start).Seconds(), "tokens/s")
+}
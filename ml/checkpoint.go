@@ -0,0 +1,104 @@
+package ml
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"github.com/korobool/nlp4go/core"
+	"io"
+)
+
+// Kind of AveragedPerceptron checkpoints in model file header
+const AveragedPerceptronCheckpointKind = "averaged_perceptron_checkpoint"
+
+// PerceptronCheckpoint is a gob encodable wrapper of AveragedPerceptron
+// that saves its full training state (current weights, sums of weights
+// over updates, timestamps and count of updates), so training can be
+// resumed or continued after loading. AveragedPerceptron itself saves
+// only weights, so it can't be trained further the same way
+type PerceptronCheckpoint struct {
+	Model *AveragedPerceptron
+}
+
+// Serializable training state of AveragedPerceptron
+type perceptronCheckpointDump struct {
+	Features *core.Vocabulary
+	Classes  *core.Vocabulary
+	TieBreak TieBreak
+	Rounds   int
+	I        int
+	Weights  [][]float64
+	Totals   [][]float64
+	Tstamps  [][]int
+}
+
+// GobEncode implements gob.GobEncoder interface
+func (c PerceptronCheckpoint) GobEncode() ([]byte, error) {
+	if c.Model == nil {
+		return nil, errors.New("checkpoint has no model")
+	}
+	ap := c.Model
+	dump := perceptronCheckpointDump{ap.Features, ap.Classes, ap.TieBreak, ap.Rounds, ap.i, ap.weights, ap.totals, ap.tstamps}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(dump); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder interface
+func (c *PerceptronCheckpoint) GobDecode(data []byte) error {
+	var dump perceptronCheckpointDump
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&dump); err != nil {
+		return err
+	}
+	if dump.Features == nil || dump.Classes == nil {
+		return errors.New("perceptron checkpoint has no vocabularies")
+	}
+	// totals and timestamps are released by averaging
+	if dump.Totals != nil && (len(dump.Totals) != len(dump.Weights) || len(dump.Tstamps) != len(dump.Weights)) {
+		return errors.New("perceptron checkpoint state doesn't match weights")
+	}
+	for feat, weights := range dump.Weights {
+		if len(weights) > dump.Classes.Len() {
+			return errors.New("perceptron weights don't match classes")
+		}
+		if dump.Totals != nil && (len(dump.Totals[feat]) > len(weights) || len(dump.Tstamps[feat]) != len(dump.Totals[feat])) {
+			return errors.New("perceptron checkpoint state doesn't match weights")
+		}
+	}
+	c.Model = &AveragedPerceptron{
+		Features: dump.Features,
+		Classes:  dump.Classes,
+		TieBreak: dump.TieBreak,
+		Rounds:   dump.Rounds,
+		i:        dump.I,
+		weights:  dump.Weights,
+		totals:   dump.Totals,
+		tstamps:  dump.Tstamps,
+	}
+	return nil
+}
+
+// WriteCheckpoint writes full training state of model to `w` in model
+// file format
+func (ap *AveragedPerceptron) WriteCheckpoint(w io.Writer) error {
+	header := ModelHeader{Kind: AveragedPerceptronCheckpointKind, Tagset: ap.Classes.Words()}
+	return WriteModel(w, header, PerceptronCheckpoint{ap})
+}
+
+// ReadCheckpoint replaces model by one written by WriteCheckpoint
+func (ap *AveragedPerceptron) ReadCheckpoint(r io.Reader) error {
+	var checkpoint PerceptronCheckpoint
+	if err := loadModel(r, AveragedPerceptronCheckpointKind, &checkpoint); err != nil {
+		return err
+	}
+	*ap = *checkpoint.Model
+	return nil
+}
+
+// Updates returns count of updates since the start of training
+// (0 for averaged or loaded models)
+func (ap *AveragedPerceptron) Updates() int {
+	return ap.i
+}
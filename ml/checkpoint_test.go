@@ -0,0 +1,81 @@
+package ml
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestPerceptronCheckpoint(t *testing.T) {
+	examples := makeExamples(300, 30, 5, 1)
+	train := func(ap *AveragedPerceptron, examples []example) {
+		for _, ex := range examples {
+			ap.UpdateFeatures(ex.class, ap.PredictFeatures(ex.features), ex.features)
+		}
+	}
+
+	// training interrupted after half of examples and resumed from
+	// checkpoint must give the same model as uninterrupted one
+	full := NewAveragedPerceptron()
+	train(full, examples)
+	full.AverageWeights()
+
+	ap := NewAveragedPerceptron()
+	train(ap, examples[:150])
+	var buf bytes.Buffer
+	if err := ap.WriteCheckpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+	resumed := NewAveragedPerceptron()
+	if err := resumed.ReadCheckpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Updates() != 150 {
+		t.Fatalf("wrong count of updates: %d", resumed.Updates())
+	}
+	train(resumed, examples[150:])
+	resumed.AverageWeights()
+
+	if !reflect.DeepEqual(resumed.ExportWeights(), full.ExportWeights()) {
+		t.Fatal("resumed model differs from uninterrupted one")
+	}
+	if resumed.Updates() != 0 {
+		t.Fatal("averaged model has training state")
+	}
+
+	// checkpoint of averaged model is just a model
+	buf.Reset()
+	if err := full.WriteCheckpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := resumed.ReadCheckpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resumed.ExportWeights(), full.ExportWeights()) {
+		t.Fatal("checkpoint of averaged model differs")
+	}
+
+	buf.Reset()
+	if err := full.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := resumed.ReadCheckpoint(&buf); err == nil {
+		t.Fatal("expected error for model file of other kind")
+	}
+}
+
+func TestAveragedCopy(t *testing.T) {
+	examples := makeExamples(200, 20, 4, 2)
+	ap := NewAveragedPerceptron()
+	for _, ex := range examples {
+		ap.UpdateFeatures(ex.class, ap.PredictFeatures(ex.features), ex.features)
+	}
+	averaged := ap.Averaged()
+	if averaged.Updates() != 0 || ap.Updates() != len(examples) {
+		t.Fatal("wrong training state of averaged copy")
+	}
+	ap.AverageWeights()
+	if !reflect.DeepEqual(averaged.ExportWeights(), ap.ExportWeights()) {
+		t.Fatal("averaged copy differs from averaged model")
+	}
+}
@@ -0,0 +1,101 @@
+package ml
+
+import (
+	"github.com/korobool/nlp4go/core"
+	"io"
+	"sort"
+)
+
+// Sparse feature vector: feature name -> value
+type Features map[string]float64
+
+// Training sample of classifier
+type Sample struct {
+	Features Features
+	Class    string
+}
+
+// Classifier is a learner of classes from sparse features,
+// implementations are interchangeable in taggers and text classifiers
+type Classifier interface {
+	// Trains model on samples
+	Train(samples []Sample) error
+	// Returns the best class for features
+	Predict(features Features) string
+	// Returns probability of every class for features
+	PredictProba(features Features) map[string]float64
+	// Writes model to `w`
+	Save(w io.Writer) error
+	// Reads model written by Save
+	Load(r io.Reader) error
+}
+
+// Feature vector with IDs of features
+type sparseVector struct {
+	ids    []int32
+	values []float64
+}
+
+// Training sample with IDs of features and class
+type sparseSample struct {
+	sparseVector
+	class int32
+}
+
+// Converts features to IDs, features are sorted by name, so float sums
+// don't depend on order of map iteration. Missing features are added to
+// vocabulary if `add` is true and skipped otherwise
+func encodeFeatures(vocab *core.Vocabulary, features Features, add bool) sparseVector {
+	names := make([]string, 0, len(features))
+	for name, value := range features {
+		if value != 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	vec := sparseVector{make([]int32, 0, len(names)), make([]float64, 0, len(names))}
+	for _, name := range names {
+		var id int32
+		var ok bool
+		if add {
+			id, ok = vocab.Add(name), true
+		} else {
+			id, ok = vocab.ID(name)
+		}
+		if ok && id >= 0 {
+			vec.ids = append(vec.ids, id)
+			vec.values = append(vec.values, features[name])
+		}
+	}
+	return vec
+}
+
+// Converts samples to IDs adding features and classes to vocabularies
+func encodeSamples(featureVocab, classVocab *core.Vocabulary, samples []Sample) []sparseSample {
+	encoded := make([]sparseSample, len(samples))
+	for i, sample := range samples {
+		class, ok := classVocab.ID(sample.Class)
+		if !ok {
+			class = classVocab.Add(sample.Class)
+		}
+		encoded[i] = sparseSample{encodeFeatures(featureVocab, sample.Features, true), class}
+	}
+	return encoded
+}
+
+// Converts scores indexed by class IDs to map of probabilities
+func probaMap(classes *core.Vocabulary, scores []float64) map[string]float64 {
+	probs := Softmax(scores)
+	proba := make(map[string]float64, len(probs))
+	for class, prob := range probs {
+		proba[classes.Word(int32(class))] = prob
+	}
+	return proba
+}
+
+var (
+	_ Classifier = (*AveragedPerceptron)(nil)
+	_ Classifier = (*LogisticRegression)(nil)
+	_ Classifier = (*NaiveBayes)(nil)
+)
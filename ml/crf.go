@@ -0,0 +1,482 @@
+package ml
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"github.com/korobool/nlp4go/core"
+	"io"
+	"math"
+	"math/rand"
+)
+
+// Kind of CRF models in model file header
+const CRFKind = "crf"
+
+// Observation features of every position of sequence
+type Sequence [][]string
+
+// Training sequence of CRF
+type LabeledSequence struct {
+	Features Sequence
+	Labels   []string
+}
+
+// CRF is a linear-chain conditional random field
+//
+// Score of labeling is sum of weights of (observation feature, label)
+// pairs, transitions between adjacent labels and start and end
+// transitions. Training minimizes negative log-likelihood plus
+// L2 / 2 * |w|^2
+type CRF struct {
+	Features *core.Vocabulary
+	Labels   *core.Vocabulary
+	// Training parameters, see LogisticRegression
+	Optimizer    Optimizer
+	L2           float64
+	Iterations   int
+	LearningRate float64
+	Tolerance    float64
+	Seed         int64
+	Progress     func(iter int, loss float64)
+	// weights of (feature, label) pairs, then transitions from start and
+	// from every label, then transitions to end
+	weights []float64
+	nLabel  int
+}
+
+// Serializable state of CRF
+type crfDump struct {
+	Features *core.Vocabulary
+	Labels   *core.Vocabulary
+	Weights  []float64
+}
+
+// Training sequence with IDs of features and labels
+type crfSequence struct {
+	features [][]int32
+	labels   []int32
+}
+
+// Buffers of forward-backward algorithm
+type crfLattice struct {
+	// exp of state scores (shifted by max of position) and of transitions
+	state [][]float64
+	trans []float64
+	alpha [][]float64
+	beta  [][]float64
+	// scale factors of alpha
+	scale []float64
+}
+
+// Constructor creates CRF trained by L-BFGS with L2 regularization
+func NewCRF() *CRF {
+	return &CRF{
+		Features:     core.NewVocabulary(""),
+		Labels:       core.NewVocabulary(""),
+		Optimizer:    OptimizerLBFGS,
+		L2:           1.0,
+		Iterations:   100,
+		LearningRate: 0.1,
+		Tolerance:    1e-5,
+		Seed:         1,
+	}
+}
+
+// Offsets of parameter groups in weights vector of `size` for L labels:
+// transitions from label i (or from start if i == -1) to label j
+// are at transOffset + (i+1)*L + j, transitions to end follow them
+func crfLayout(size, nLabel int) (nFeat, transOffset, endOffset int) {
+	nFeat = (size - nLabel*(nLabel+2)) / nLabel
+	transOffset = nFeat * nLabel
+	endOffset = transOffset + (nLabel+1)*nLabel
+	return
+}
+
+// Computes scores of labels for every position
+func (crf *CRF) stateScores(x []float64, features [][]int32) [][]float64 {
+	nFeat, _, _ := crfLayout(len(x), crf.nLabel)
+	scores := make([][]float64, len(features))
+	for t, feats := range features {
+		scores[t] = make([]float64, crf.nLabel)
+		for _, f := range feats {
+			if f < 0 || int(f) >= nFeat {
+				continue
+			}
+			axpy(1, x[int(f)*crf.nLabel:int(f+1)*crf.nLabel], scores[t])
+		}
+	}
+	return scores
+}
+
+// Runs forward-backward algorithm, returns log of partition function
+func (crf *CRF) forwardBackward(x []float64, features [][]int32, lat *crfLattice) float64 {
+	L := crf.nLabel
+	T := len(features)
+	_, transOffset, endOffset := crfLayout(len(x), L)
+
+	logZ := 0.0
+	lat.state = crf.stateScores(x, features)
+	for t := range lat.state {
+		max := math.Inf(-1)
+		for _, s := range lat.state[t] {
+			max = math.Max(max, s)
+		}
+		for j, s := range lat.state[t] {
+			lat.state[t][j] = math.Exp(s - max)
+		}
+		logZ += max
+	}
+	lat.trans = make([]float64, (L+2)*L)
+	for i := range lat.trans {
+		lat.trans[i] = math.Exp(x[transOffset+i])
+	}
+	start, end := lat.trans[:L], lat.trans[endOffset-transOffset:]
+
+	lat.alpha = make([][]float64, T)
+	lat.beta = make([][]float64, T)
+	lat.scale = make([]float64, T)
+	for t := 0; t < T; t++ {
+		lat.alpha[t] = make([]float64, L)
+		for j := 0; j < L; j++ {
+			if t == 0 {
+				lat.alpha[t][j] = start[j]
+			} else {
+				var sum float64
+				for i := 0; i < L; i++ {
+					sum += lat.alpha[t-1][i] * lat.trans[(i+1)*L+j]
+				}
+				lat.alpha[t][j] = sum
+			}
+			lat.alpha[t][j] *= lat.state[t][j]
+			lat.scale[t] += lat.alpha[t][j]
+		}
+		scale(1/lat.scale[t], lat.alpha[t])
+		logZ += math.Log(lat.scale[t])
+	}
+	var final float64
+	for j := 0; j < L; j++ {
+		final += lat.alpha[T-1][j] * end[j]
+	}
+	logZ += math.Log(final)
+
+	for t := T - 1; t >= 0; t-- {
+		lat.beta[t] = make([]float64, L)
+		for i := 0; i < L; i++ {
+			if t == T-1 {
+				lat.beta[t][i] = end[i] / final
+				continue
+			}
+			var sum float64
+			for j := 0; j < L; j++ {
+				sum += lat.trans[(i+1)*L+j] * lat.state[t+1][j] * lat.beta[t+1][j]
+			}
+			lat.beta[t][i] = sum / lat.scale[t+1]
+		}
+	}
+	return logZ
+}
+
+// Returns score of labeling
+func (crf *CRF) pathScore(x []float64, seq crfSequence) float64 {
+	L := crf.nLabel
+	nFeat, transOffset, endOffset := crfLayout(len(x), L)
+	var score float64
+	prev := int32(-1)
+	for t, label := range seq.labels {
+		for _, f := range seq.features[t] {
+			if f >= 0 && int(f) < nFeat {
+				score += x[int(f)*L+int(label)]
+			}
+		}
+		score += x[transOffset+int(prev+1)*L+int(label)]
+		prev = label
+	}
+	return score + x[endOffset+int(prev)]
+}
+
+// Adds gradient of negative log-likelihood of sequence multiplied
+// by `factor` to `grad`, returns negative log-likelihood
+func (crf *CRF) addGradient(x, grad []float64, seq crfSequence, factor float64, lat *crfLattice) float64 {
+	L := crf.nLabel
+	T := len(seq.labels)
+	_, transOffset, endOffset := crfLayout(len(x), L)
+	logZ := crf.forwardBackward(x, seq.features, lat)
+
+	marginal := make([]float64, L)
+	for t := 0; t < T; t++ {
+		// marginals of positions are normalized by construction of beta
+		for j := 0; j < L; j++ {
+			marginal[j] = lat.alpha[t][j] * lat.beta[t][j]
+		}
+		marginal[seq.labels[t]] -= 1
+		for _, f := range seq.features[t] {
+			axpy(factor, marginal, grad[int(f)*L:int(f+1)*L])
+		}
+		if t == 0 {
+			axpy(factor, marginal, grad[transOffset:transOffset+L])
+		} else {
+			for i := 0; i < L; i++ {
+				for j := 0; j < L; j++ {
+					p := lat.alpha[t-1][i] * lat.trans[(i+1)*L+j] * lat.state[t][j] * lat.beta[t][j] / lat.scale[t]
+					grad[transOffset+(i+1)*L+j] += factor * p
+				}
+			}
+			prev := seq.labels[t-1]
+			grad[transOffset+int(prev+1)*L+int(seq.labels[t])] -= factor
+		}
+		if t == T-1 {
+			axpy(factor, marginal, grad[endOffset:endOffset+L])
+		}
+	}
+	return logZ - crf.pathScore(x, seq)
+}
+
+func (crf *CRF) encodeSequence(features Sequence, add bool) [][]int32 {
+	ids := make([][]int32, len(features))
+	for t, feats := range features {
+		ids[t] = make([]int32, 0, len(feats))
+		for _, feat := range feats {
+			var id int32
+			var ok bool
+			if add {
+				id, ok = crf.Features.Add(feat), true
+			} else {
+				id, ok = crf.Features.ID(feat)
+			}
+			if ok && id >= 0 {
+				ids[t] = append(ids[t], id)
+			}
+		}
+	}
+	return ids
+}
+
+// Train trains CRF on sequences from scratch
+func (crf *CRF) Train(sequences []LabeledSequence) error {
+	encoded := make([]crfSequence, 0, len(sequences))
+	for i, seq := range sequences {
+		if len(seq.Features) != len(seq.Labels) {
+			return fmt.Errorf("sequence %d: %d positions and %d labels", i, len(seq.Features), len(seq.Labels))
+		}
+		if len(seq.Labels) == 0 {
+			continue
+		}
+		labels := make([]int32, len(seq.Labels))
+		for t, label := range seq.Labels {
+			id, ok := crf.Labels.ID(label)
+			if !ok {
+				id = crf.Labels.Add(label)
+			}
+			labels[t] = id
+		}
+		encoded = append(encoded, crfSequence{crf.encodeSequence(seq.Features, true), labels})
+	}
+	if len(encoded) == 0 {
+		return errors.New("no training sequences")
+	}
+	crf.nLabel = crf.Labels.Len()
+	x := make([]float64, crf.Features.Len()*crf.nLabel+crf.nLabel*(crf.nLabel+2))
+
+	if crf.Optimizer == OptimizerSGD {
+		crf.trainSGD(encoded, x)
+	} else {
+		crf.trainLBFGS(encoded, x)
+	}
+	crf.weights = x
+	return nil
+}
+
+func (crf *CRF) trainLBFGS(sequences []crfSequence, x []float64) {
+	lat := &crfLattice{}
+	objective := func(x, grad []float64) float64 {
+		for i := range grad {
+			grad[i] = 0
+		}
+		var loss float64
+		for _, seq := range sequences {
+			loss += crf.addGradient(x, grad, seq, 1, lat)
+		}
+		if crf.L2 > 0 {
+			for i, w := range x {
+				loss += crf.L2 / 2 * w * w
+				grad[i] += crf.L2 * w
+			}
+		}
+		return loss
+	}
+	opts := lbfgsOptions{maxIter: crf.Iterations, tolerance: crf.Tolerance}
+	if crf.Progress != nil {
+		opts.progress = func(iter int, value float64) bool {
+			crf.Progress(iter, value)
+			return true
+		}
+	}
+	minimizeLBFGS(objective, x, opts)
+}
+
+// SGD with learning rate decay, L2 is applied to weights of features
+// present in sequence and to transitions (scaled by 1 / count of sequences)
+func (crf *CRF) trainSGD(sequences []crfSequence, x []float64) {
+	lat := &crfLattice{}
+	grad := make([]float64, len(x))
+	L := crf.nLabel
+	_, transOffset, _ := crfLayout(len(x), L)
+	n := float64(len(sequences))
+	// step when feature was updated last time, so it's updated once per step
+	updated := make([]int, transOffset/L)
+	rnd := rand.New(rand.NewSource(crf.Seed))
+	order := rnd.Perm(len(sequences))
+
+	step := func(from, to int, eta float64) {
+		for j := from; j < to; j++ {
+			x[j] -= eta * (grad[j] + crf.L2/n*x[j])
+			grad[j] = 0
+		}
+	}
+	t := 0
+	for epoch := 0; epoch < crf.Iterations; epoch++ {
+		var loss float64
+		for _, idx := range order {
+			seq := sequences[idx]
+			eta := crf.LearningRate / (1 + float64(t)/n)
+			t++
+			loss += crf.addGradient(x, grad, seq, 1, lat)
+			for _, feats := range seq.features {
+				for _, f := range feats {
+					if updated[f] == t {
+						continue
+					}
+					updated[f] = t
+					step(int(f)*L, int(f+1)*L, eta)
+				}
+			}
+			step(transOffset, len(x), eta)
+		}
+		if crf.Progress != nil {
+			crf.Progress(epoch+1, loss)
+		}
+		rnd.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	}
+}
+
+// Tag returns the most probable labeling of sequence (Viterbi decoding)
+func (crf *CRF) Tag(features Sequence) []string {
+	labels, _ := crf.Viterbi(features)
+	return labels
+}
+
+// Viterbi returns the most probable labeling and its score
+func (crf *CRF) Viterbi(features Sequence) ([]string, float64) {
+	T, L := len(features), crf.nLabel
+	if T == 0 || L == 0 {
+		return []string{}, 0
+	}
+	x := crf.weights
+	_, transOffset, endOffset := crfLayout(len(x), L)
+	state := crf.stateScores(x, crf.encodeSequence(features, false))
+
+	score := make([][]float64, T)
+	back := make([][]int, T)
+	for t := 0; t < T; t++ {
+		score[t] = make([]float64, L)
+		back[t] = make([]int, L)
+		for j := 0; j < L; j++ {
+			if t == 0 {
+				score[t][j] = x[transOffset+j] + state[t][j]
+				continue
+			}
+			best := math.Inf(-1)
+			for i := 0; i < L; i++ {
+				if s := score[t-1][i] + x[transOffset+(i+1)*L+j]; s > best {
+					best, back[t][j] = s, i
+				}
+			}
+			score[t][j] = best + state[t][j]
+		}
+	}
+	bestLast, bestScore := 0, math.Inf(-1)
+	for j := 0; j < L; j++ {
+		if s := score[T-1][j] + x[endOffset+j]; s > bestScore {
+			bestLast, bestScore = j, s
+		}
+	}
+	labels := make([]string, T)
+	for t, j := T-1, bestLast; t >= 0; t-- {
+		labels[t] = crf.Labels.Word(int32(j))
+		j = back[t][j]
+	}
+	return labels, bestScore
+}
+
+// Marginals returns probability of every label at every position
+func (crf *CRF) Marginals(features Sequence) []map[string]float64 {
+	marginals := make([]map[string]float64, len(features))
+	if len(features) == 0 || crf.nLabel == 0 {
+		return marginals
+	}
+	lat := &crfLattice{}
+	crf.forwardBackward(crf.weights, crf.encodeSequence(features, false), lat)
+	for t := range features {
+		marginals[t] = make(map[string]float64, crf.nLabel)
+		for j := 0; j < crf.nLabel; j++ {
+			marginals[t][crf.Labels.Word(int32(j))] = lat.alpha[t][j] * lat.beta[t][j]
+		}
+	}
+	return marginals
+}
+
+// Probability returns conditional probability of labeling of sequence
+func (crf *CRF) Probability(features Sequence, labels []string) float64 {
+	if len(features) != len(labels) || len(labels) == 0 {
+		return 0
+	}
+	seq := crfSequence{crf.encodeSequence(features, false), make([]int32, len(labels))}
+	for t, label := range labels {
+		id, ok := crf.Labels.ID(label)
+		if !ok {
+			return 0
+		}
+		seq.labels[t] = id
+	}
+	logZ := crf.forwardBackward(crf.weights, seq.features, &crfLattice{})
+	return math.Exp(crf.pathScore(crf.weights, seq) - logZ)
+}
+
+// GobEncode implements gob.GobEncoder interface
+func (crf *CRF) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(crfDump{crf.Features, crf.Labels, crf.weights}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder interface
+func (crf *CRF) GobDecode(data []byte) error {
+	var dump crfDump
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&dump); err != nil {
+		return err
+	}
+	if dump.Features == nil || dump.Labels == nil {
+		return errors.New("crf has no vocabularies")
+	}
+	nLabel := dump.Labels.Len()
+	if len(dump.Weights) != dump.Features.Len()*nLabel+nLabel*(nLabel+2) {
+		return errors.New("crf weights don't match vocabularies")
+	}
+	crf.Features, crf.Labels, crf.weights, crf.nLabel = dump.Features, dump.Labels, dump.Weights, nLabel
+	return nil
+}
+
+// Save writes model to `w` in model file format
+func (crf *CRF) Save(w io.Writer) error {
+	header := ModelHeader{Kind: CRFKind, Tagset: crf.Labels.Words()}
+	return WriteModel(w, header, crf)
+}
+
+// Load reads model written by Save
+func (crf *CRF) Load(r io.Reader) error {
+	return loadModel(r, CRFKind, crf)
+}
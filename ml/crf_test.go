@@ -0,0 +1,179 @@
+package ml
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Sequences where tag of ambiguous word "x" depends on previous tag:
+// it's N after D and V otherwise
+func makeCRFSequences(count int, seed int64) []LabeledSequence {
+	rnd := rand.New(rand.NewSource(seed))
+	words := map[string]string{"D": "the", "N": "dog", "V": "runs"}
+	tags := []string{"D", "N", "V"}
+	sequences := make([]LabeledSequence, count)
+	for i := range sequences {
+		n := 2 + rnd.Intn(6)
+		seq := LabeledSequence{Features: make(Sequence, n), Labels: make([]string, n)}
+		for j := 0; j < n; j++ {
+			word := "x"
+			if rnd.Intn(2) == 0 || j == 0 {
+				seq.Labels[j] = tags[rnd.Intn(len(tags))]
+				word = words[seq.Labels[j]]
+			} else if seq.Labels[j-1] == "D" {
+				seq.Labels[j] = "N"
+			} else {
+				seq.Labels[j] = "V"
+			}
+			seq.Features[j] = []string{"bias", "word=" + word}
+		}
+		sequences[i] = seq
+	}
+	return sequences
+}
+
+// CRF with random weights, 3 labels and 4 features
+func makeRandomCRF(seed int64) (*CRF, Sequence) {
+	rnd := rand.New(rand.NewSource(seed))
+	crf := NewCRF()
+	for _, label := range []string{"A", "B", "C"} {
+		crf.Labels.Add(label)
+	}
+	for f := 0; f < 4; f++ {
+		crf.Features.Add(fmt.Sprintf("f%d", f))
+	}
+	crf.nLabel = 3
+	crf.weights = make([]float64, 4*3+3*5)
+	for i := range crf.weights {
+		crf.weights[i] = rnd.NormFloat64() * 2
+	}
+	seq := Sequence{{"f0", "f1"}, {"f2"}, {"f3", "f0", "unknown"}, {"f1"}}
+	return crf, seq
+}
+
+// Calls fn for every labeling of sequence of length `n`
+func enumerateLabelings(labels []string, n int, fn func([]string)) {
+	labeling := make([]string, n)
+	var rec func(int)
+	rec = func(t int) {
+		if t == n {
+			fn(labeling)
+			return
+		}
+		for _, label := range labels {
+			labeling[t] = label
+			rec(t + 1)
+		}
+	}
+	rec(0)
+}
+
+func TestCRFInference(t *testing.T) {
+	crf, seq := makeRandomCRF(1)
+	labels := crf.Labels.Words()
+
+	// brute force probabilities of all labelings
+	var total float64
+	var bestScore float64
+	var best []string
+	marginals := make([]map[string]float64, len(seq))
+	for i := range marginals {
+		marginals[i] = make(map[string]float64)
+	}
+	enumerateLabelings(labels, len(seq), func(labeling []string) {
+		p := crf.Probability(seq, labeling)
+		total += p
+		for i, label := range labeling {
+			marginals[i][label] += p
+		}
+		if best == nil || p > bestScore {
+			best, bestScore = append([]string(nil), labeling...), p
+		}
+	})
+	if math.Abs(total-1) > 1e-9 {
+		t.Fatalf("probabilities of labelings sum to %f", total)
+	}
+	if tags := crf.Tag(seq); fmt.Sprint(tags) != fmt.Sprint(best) {
+		t.Fatalf("viterbi returned %v, the best labeling is %v", tags, best)
+	}
+	for i, probs := range crf.Marginals(seq) {
+		for label, p := range probs {
+			if math.Abs(p-marginals[i][label]) > 1e-9 {
+				t.Fatalf("wrong marginal of %s at %d: %f != %f", label, i, p, marginals[i][label])
+			}
+		}
+	}
+}
+
+func TestCRFGradient(t *testing.T) {
+	crf, seq := makeRandomCRF(2)
+	encoded := crfSequence{crf.encodeSequence(seq, false), []int32{0, 2, 1, 1}}
+	x := crf.weights
+	grad := make([]float64, len(x))
+	lat := &crfLattice{}
+	crf.addGradient(x, grad, encoded, 1, lat)
+
+	nll := func() float64 {
+		return crf.addGradient(x, make([]float64, len(x)), encoded, 1, lat)
+	}
+	const eps = 1e-6
+	for i := range x {
+		orig := x[i]
+		x[i] = orig + eps
+		plus := nll()
+		x[i] = orig - eps
+		minus := nll()
+		x[i] = orig
+		if numeric := (plus - minus) / (2 * eps); math.Abs(numeric-grad[i]) > 1e-5 {
+			t.Fatalf("wrong gradient of weight %d: %f, numeric %f", i, grad[i], numeric)
+		}
+	}
+}
+
+func TestCRFTrain(t *testing.T) {
+	train := makeCRFSequences(300, 1)
+	test := makeCRFSequences(200, 2)
+
+	sgd := NewCRF()
+	sgd.Optimizer = OptimizerSGD
+	sgd.Iterations = 10
+
+	for name, crf := range map[string]*CRF{"lbfgs": NewCRF(), "sgd": sgd} {
+		if err := crf.Train(train); err != nil {
+			t.Fatalf("%s: can't train: %v", name, err)
+		}
+		var correct, total int
+		for _, seq := range test {
+			for i, tag := range crf.Tag(seq.Features) {
+				if tag == seq.Labels[i] {
+					correct++
+				}
+				total++
+			}
+		}
+		if acc := float64(correct) / float64(total); acc < 0.99 {
+			t.Fatalf("%s: accuracy is too low: %.3f", name, acc)
+		}
+
+		var buf bytes.Buffer
+		if err := crf.Save(&buf); err != nil {
+			t.Fatalf("%s: can't save: %v", name, err)
+		}
+		loaded := &CRF{}
+		if err := loaded.Load(&buf); err != nil {
+			t.Fatalf("%s: can't load: %v", name, err)
+		}
+		for _, seq := range test[:20] {
+			if fmt.Sprint(loaded.Tag(seq.Features)) != fmt.Sprint(crf.Tag(seq.Features)) {
+				t.Fatalf("%s: loaded model predicts differently", name)
+			}
+		}
+	}
+
+	if err := NewCRF().Train([]LabeledSequence{{Features: Sequence{{"a"}}, Labels: nil}}); err == nil {
+		t.Fatal("expected error for sequence without labels")
+	}
+}
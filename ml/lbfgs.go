@@ -0,0 +1,219 @@
+package ml
+
+import "math"
+
+// Objective function for minimization: returns value at `x`
+// and stores gradient to `grad`
+type objectiveFunc func(x, grad []float64) float64
+
+// Options of L-BFGS minimization
+type lbfgsOptions struct {
+	// Count of stored correction pairs
+	memory int
+	// Max count of iterations
+	maxIter int
+	// Minimization stops when relative decrease of objective
+	// is less than tolerance
+	tolerance float64
+	// Coefficient of L1 regularization, if it's positive, orthant-wise
+	// L-BFGS (OWL-QN) is used to minimize f(x) + l1 * |x|_1
+	l1 float64
+	// Count of first elements of `x` that are L1 regularized,
+	// all elements if zero
+	l1Size int
+	// Called after every iteration with iteration number and objective value,
+	// minimization stops if it returns false
+	progress func(iter int, value float64) bool
+}
+
+// Minimizes objective by limited-memory BFGS (with OWL-QN extension
+// for L1 regularization). `x` is starting point and it's updated in place.
+// Returns final value of objective (including L1 penalty) and count
+// of iterations
+func minimizeLBFGS(f objectiveFunc, x []float64, opts lbfgsOptions) (float64, int) {
+	n := len(x)
+	if opts.memory <= 0 {
+		opts.memory = 10
+	}
+	if opts.maxIter <= 0 {
+		opts.maxIter = 100
+	}
+	if opts.tolerance <= 0 {
+		opts.tolerance = 1e-5
+	}
+	l1Size := opts.l1Size
+	if l1Size <= 0 || l1Size > n {
+		l1Size = n
+	}
+	penalty := func(x []float64) float64 {
+		if opts.l1 <= 0 {
+			return 0
+		}
+		var sum float64
+		for _, v := range x[:l1Size] {
+			sum += math.Abs(v)
+		}
+		return opts.l1 * sum
+	}
+
+	grad := make([]float64, n)
+	pgrad := make([]float64, n)
+	dir := make([]float64, n)
+	newX := make([]float64, n)
+	newGrad := make([]float64, n)
+	s := make([][]float64, 0, opts.memory)
+	y := make([][]float64, 0, opts.memory)
+	rho := make([]float64, 0, opts.memory)
+	alpha := make([]float64, opts.memory)
+
+	value := f(x, grad) + penalty(x)
+	iter := 0
+	for ; iter < opts.maxIter; iter++ {
+		opts.pseudoGradient(x, grad, pgrad, l1Size)
+		if norm(pgrad) < 1e-10 {
+			break
+		}
+
+		// two-loop recursion: dir = -H * pgrad
+		copy(dir, pgrad)
+		for i := len(s) - 1; i >= 0; i-- {
+			alpha[i] = rho[i] * dot(s[i], dir)
+			axpy(-alpha[i], y[i], dir)
+		}
+		if k := len(s) - 1; k >= 0 {
+			scale(dot(s[k], y[k])/dot(y[k], y[k]), dir)
+		}
+		for i := range s {
+			beta := rho[i] * dot(y[i], dir)
+			axpy(alpha[i]-beta, s[i], dir)
+		}
+		scale(-1, dir)
+		if opts.l1 > 0 {
+			// direction must agree with steepest descent of pseudo-gradient
+			for i := 0; i < l1Size; i++ {
+				if dir[i]*pgrad[i] >= 0 {
+					dir[i] = 0
+				}
+			}
+		}
+		slope := dot(dir, pgrad)
+		if slope >= 0 {
+			// not a descent direction, restart from steepest descent
+			s, y, rho = s[:0], y[:0], rho[:0]
+			copy(dir, pgrad)
+			scale(-1, dir)
+			slope = dot(dir, pgrad)
+		}
+
+		// backtracking line search with Armijo condition
+		step := 1.0
+		if len(s) == 0 {
+			step = 1 / math.Max(norm(dir), 1)
+		}
+		var newValue float64
+		found := false
+		for ls := 0; ls < 40; ls++ {
+			copy(newX, x)
+			axpy(step, dir, newX)
+			if opts.l1 > 0 {
+				// stay in orthant of current point
+				for i := 0; i < l1Size; i++ {
+					orthant := x[i]
+					if orthant == 0 {
+						orthant = -pgrad[i]
+					}
+					if newX[i]*orthant <= 0 {
+						newX[i] = 0
+					}
+				}
+			}
+			newValue = f(newX, newGrad) + penalty(newX)
+			var decrease float64
+			for i := range x {
+				decrease += pgrad[i] * (newX[i] - x[i])
+			}
+			if newValue <= value+1e-4*decrease {
+				found = true
+				break
+			}
+			step /= 2
+		}
+		if !found {
+			break
+		}
+
+		// store correction pair
+		si, yi := make([]float64, n), make([]float64, n)
+		for i := range x {
+			si[i] = newX[i] - x[i]
+			yi[i] = newGrad[i] - grad[i]
+		}
+		if sy := dot(si, yi); sy > 1e-10 {
+			if len(s) == opts.memory {
+				s, y, rho = s[1:], y[1:], rho[1:]
+			}
+			s, y, rho = append(s, si), append(y, yi), append(rho, 1/sy)
+		}
+
+		improvement := (value - newValue) / math.Max(math.Abs(value), 1)
+		copy(x, newX)
+		copy(grad, newGrad)
+		value = newValue
+		if opts.progress != nil && !opts.progress(iter+1, value) {
+			iter++
+			break
+		}
+		if improvement < opts.tolerance {
+			iter++
+			break
+		}
+	}
+	return value, iter
+}
+
+// Computes pseudo-gradient of f(x) + l1 * |x|_1 (just gradient if l1 is zero)
+func (opts lbfgsOptions) pseudoGradient(x, grad, pgrad []float64, l1Size int) {
+	copy(pgrad, grad)
+	if opts.l1 <= 0 {
+		return
+	}
+	for i := 0; i < l1Size; i++ {
+		switch {
+		case x[i] > 0:
+			pgrad[i] = grad[i] + opts.l1
+		case x[i] < 0:
+			pgrad[i] = grad[i] - opts.l1
+		case grad[i]+opts.l1 < 0:
+			pgrad[i] = grad[i] + opts.l1
+		case grad[i]-opts.l1 > 0:
+			pgrad[i] = grad[i] - opts.l1
+		default:
+			pgrad[i] = 0
+		}
+	}
+}
+
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func norm(a []float64) float64 {
+	return math.Sqrt(dot(a, a))
+}
+
+// y += alpha * x
+func axpy(alpha float64, x, y []float64) {
+	for i := range x {
+		y[i] += alpha * x[i]
+	}
+}
+
+func scale(alpha float64, x []float64) {
+	for i := range x {
+		x[i] *= alpha
+	}
+}
@@ -0,0 +1,301 @@
+package ml
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"github.com/korobool/nlp4go/core"
+	"io"
+	"math"
+	"math/rand"
+)
+
+// Kind of LogisticRegression models in model file header
+const LogisticRegressionKind = "logistic_regression"
+
+// Optimization method of LogisticRegression
+type Optimizer int
+
+const (
+	// Batch L-BFGS (OWL-QN if L1 is set)
+	OptimizerLBFGS Optimizer = iota
+	// Stochastic gradient descent with cumulative L1 penalty
+	OptimizerSGD
+)
+
+// LogisticRegression is a multinomial logistic regression
+// (maximum entropy) classifier
+//
+// It minimizes negative log-likelihood of training samples plus
+// L1 * |w|_1 + L2 / 2 * |w|^2 (biases aren't regularized)
+type LogisticRegression struct {
+	Features *core.Vocabulary
+	Classes  *core.Vocabulary
+	// Training parameters
+	Optimizer Optimizer
+	L1        float64
+	L2        float64
+	// Max count of L-BFGS iterations or count of SGD epochs
+	Iterations int
+	// Initial learning rate of SGD
+	LearningRate float64
+	// L-BFGS stops when relative decrease of objective is less than Tolerance
+	Tolerance float64
+	Seed      int64
+	// Optional callback called after every iteration (epoch)
+	// with value of objective
+	Progress func(iter int, loss float64)
+	// weights[feature * classes + class], biases follow weights
+	weights []float64
+	nClass  int
+}
+
+// Serializable state of LogisticRegression
+type logisticRegressionDump struct {
+	Features *core.Vocabulary
+	Classes  *core.Vocabulary
+	Weights  []float64
+}
+
+// Constructor creates model with L2 regularization trained by L-BFGS
+func NewLogisticRegression() *LogisticRegression {
+	return &LogisticRegression{
+		Features:     core.NewVocabulary(""),
+		Classes:      core.NewVocabulary(""),
+		Optimizer:    OptimizerLBFGS,
+		L2:           1.0,
+		Iterations:   100,
+		LearningRate: 0.1,
+		Tolerance:    1e-5,
+		Seed:         1,
+	}
+}
+
+// Computes scores of classes into `scores`
+func (lr *LogisticRegression) scoresInto(x []float64, scores []float64, vec sparseVector) {
+	nFeat := len(x)/lr.nClass - 1
+	copy(scores, x[nFeat*lr.nClass:])
+	for i, feat := range vec.ids {
+		if int(feat) >= nFeat {
+			continue
+		}
+		row := x[int(feat)*lr.nClass : int(feat+1)*lr.nClass]
+		for class, weight := range row {
+			scores[class] += vec.values[i] * weight
+		}
+	}
+}
+
+// Converts scores to probabilities in place, returns log of normalizer
+func softmaxInPlace(scores []float64) float64 {
+	max := math.Inf(-1)
+	for _, score := range scores {
+		max = math.Max(max, score)
+	}
+	var sum float64
+	for i, score := range scores {
+		scores[i] = math.Exp(score - max)
+		sum += scores[i]
+	}
+	for i := range scores {
+		scores[i] /= sum
+	}
+	return max + math.Log(sum)
+}
+
+// Train trains model on samples from scratch
+func (lr *LogisticRegression) Train(samples []Sample) error {
+	if len(samples) == 0 {
+		return errors.New("no training samples")
+	}
+	encoded := encodeSamples(lr.Features, lr.Classes, samples)
+	lr.nClass = lr.Classes.Len()
+	nFeat := lr.Features.Len()
+	x := make([]float64, (nFeat+1)*lr.nClass)
+
+	if lr.Optimizer == OptimizerSGD {
+		lr.trainSGD(encoded, x, nFeat)
+	} else {
+		lr.trainLBFGS(encoded, x, nFeat)
+	}
+	lr.weights = x
+	return nil
+}
+
+func (lr *LogisticRegression) trainLBFGS(samples []sparseSample, x []float64, nFeat int) {
+	probs := make([]float64, lr.nClass)
+	nWeights := nFeat * lr.nClass
+	objective := func(x, grad []float64) float64 {
+		for i := range grad {
+			grad[i] = 0
+		}
+		var loss float64
+		for _, sample := range samples {
+			lr.scoresInto(x, probs, sample.sparseVector)
+			correct := probs[sample.class]
+			loss += softmaxInPlace(probs) - correct
+			probs[sample.class] -= 1
+			for i, feat := range sample.ids {
+				row := grad[int(feat)*lr.nClass : int(feat+1)*lr.nClass]
+				axpy(sample.values[i], probs, row)
+			}
+			axpy(1, probs, grad[nWeights:])
+		}
+		if lr.L2 > 0 {
+			for i, w := range x[:nWeights] {
+				loss += lr.L2 / 2 * w * w
+				grad[i] += lr.L2 * w
+			}
+		}
+		return loss
+	}
+	opts := lbfgsOptions{
+		maxIter:   lr.Iterations,
+		tolerance: lr.Tolerance,
+		l1:        lr.L1,
+		l1Size:    nWeights,
+	}
+	if lr.Progress != nil {
+		opts.progress = func(iter int, value float64) bool {
+			lr.Progress(iter, value)
+			return true
+		}
+	}
+	minimizeLBFGS(objective, x, opts)
+}
+
+// SGD with learning rate decay, L2 is applied to weights of features
+// present in sample (scaled by 1 / count of samples), L1 is applied by
+// cumulative penalty (Tsuruoka et al., 2009)
+func (lr *LogisticRegression) trainSGD(samples []sparseSample, x []float64, nFeat int) {
+	probs := make([]float64, lr.nClass)
+	nWeights := nFeat * lr.nClass
+	n := float64(len(samples))
+	// total L1 penalty and penalty received by every weight
+	var u float64
+	var q []float64
+	if lr.L1 > 0 {
+		q = make([]float64, nWeights)
+	}
+	rnd := rand.New(rand.NewSource(lr.Seed))
+	order := rnd.Perm(len(samples))
+
+	t := 0
+	for epoch := 0; epoch < lr.Iterations; epoch++ {
+		var loss float64
+		for _, idx := range order {
+			sample := samples[idx]
+			eta := lr.LearningRate / (1 + float64(t)/n)
+			t++
+
+			lr.scoresInto(x, probs, sample.sparseVector)
+			correct := probs[sample.class]
+			loss += softmaxInPlace(probs) - correct
+			probs[sample.class] -= 1
+
+			u += eta * lr.L1 / n
+			for i, feat := range sample.ids {
+				offset := int(feat) * lr.nClass
+				for class, p := range probs {
+					j := offset + class
+					x[j] -= eta * (sample.values[i]*p + lr.L2/n*x[j])
+					if q != nil {
+						z := x[j]
+						if x[j] > 0 {
+							x[j] = math.Max(0, x[j]-(u+q[j]))
+						} else if x[j] < 0 {
+							x[j] = math.Min(0, x[j]+(u-q[j]))
+						}
+						q[j] += x[j] - z
+					}
+				}
+			}
+			axpy(-eta, probs, x[nWeights:])
+		}
+		if lr.Progress != nil {
+			lr.Progress(epoch+1, loss)
+		}
+		rnd.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	}
+}
+
+// Returns probabilities of classes indexed by class IDs
+func (lr *LogisticRegression) probs(features Features) []float64 {
+	scores := make([]float64, lr.nClass)
+	if lr.nClass == 0 {
+		return scores
+	}
+	lr.scoresInto(lr.weights, scores, encodeFeatures(lr.Features, features, false))
+	softmaxInPlace(scores)
+	return scores
+}
+
+// Predict returns the most probable class
+func (lr *LogisticRegression) Predict(features Features) string {
+	probs := lr.probs(features)
+	best := -1
+	for class, prob := range probs {
+		if best < 0 || prob > probs[best] {
+			best = class
+		}
+	}
+	return lr.Classes.Word(int32(best))
+}
+
+// PredictProba returns probabilities of all classes
+func (lr *LogisticRegression) PredictProba(features Features) map[string]float64 {
+	probs := lr.probs(features)
+	proba := make(map[string]float64, len(probs))
+	for class, prob := range probs {
+		proba[lr.Classes.Word(int32(class))] = prob
+	}
+	return proba
+}
+
+// Weight returns weight of feature for class (0 if feature is unknown)
+func (lr *LogisticRegression) Weight(feature, class string) float64 {
+	f, ok := lr.Features.ID(feature)
+	c, ok2 := lr.Classes.ID(class)
+	if !ok || !ok2 || int(c) >= lr.nClass || int(f) >= len(lr.weights)/lr.nClass-1 {
+		return 0
+	}
+	return lr.weights[int(f)*lr.nClass+int(c)]
+}
+
+// GobEncode implements gob.GobEncoder interface
+func (lr *LogisticRegression) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	dump := logisticRegressionDump{lr.Features, lr.Classes, lr.weights}
+	if err := gob.NewEncoder(&buf).Encode(dump); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder interface
+func (lr *LogisticRegression) GobDecode(data []byte) error {
+	var dump logisticRegressionDump
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&dump); err != nil {
+		return err
+	}
+	if dump.Features == nil || dump.Classes == nil {
+		return errors.New("logistic regression has no vocabularies")
+	}
+	nClass := dump.Classes.Len()
+	if len(dump.Weights) != (dump.Features.Len()+1)*nClass {
+		return errors.New("logistic regression weights don't match vocabularies")
+	}
+	lr.Features, lr.Classes, lr.weights, lr.nClass = dump.Features, dump.Classes, dump.Weights, nClass
+	return nil
+}
+
+// Save writes model to `w` in model file format
+func (lr *LogisticRegression) Save(w io.Writer) error {
+	header := ModelHeader{Kind: LogisticRegressionKind, Tagset: lr.Classes.Words()}
+	return WriteModel(w, header, lr)
+}
+
+// Load reads model written by Save
+func (lr *LogisticRegression) Load(r io.Reader) error {
+	return loadModel(r, LogisticRegressionKind, lr)
+}
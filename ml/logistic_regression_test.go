@@ -0,0 +1,119 @@
+package ml
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Samples of 3 classes, class is determined by one of "signal" features,
+// "noise" features are random
+func makeClassificationSamples(count int, seed int64) []Sample {
+	rnd := rand.New(rand.NewSource(seed))
+	samples := make([]Sample, count)
+	for i := range samples {
+		class := rnd.Intn(3)
+		features := Features{
+			fmt.Sprintf("signal=%d", class):        1,
+			fmt.Sprintf("noise=%d", rnd.Intn(20)):  1,
+			fmt.Sprintf("noise2=%d", rnd.Intn(20)): 0.5,
+		}
+		// weaker signal for some samples
+		if rnd.Intn(4) == 0 {
+			features = Features{fmt.Sprintf("weak=%d", class): 1, fmt.Sprintf("noise=%d", rnd.Intn(20)): 1}
+		}
+		samples[i] = Sample{features, fmt.Sprintf("c%d", class)}
+	}
+	return samples
+}
+
+func TestClassifiers(t *testing.T) {
+	train := makeClassificationSamples(600, 1)
+	test := makeClassificationSamples(300, 2)
+
+	sgd := NewLogisticRegression()
+	sgd.Optimizer = OptimizerSGD
+	sgd.Iterations = 10
+
+	l1 := NewLogisticRegression()
+	l1.L1, l1.L2 = 1, 0
+
+	classifiers := map[string]Classifier{
+		"perceptron": NewAveragedPerceptron(),
+		"lbfgs":      NewLogisticRegression(),
+		"lbfgs-l1":   l1,
+		"sgd":        sgd,
+	}
+	for name, c := range classifiers {
+		if err := c.Train(train); err != nil {
+			t.Fatalf("%s: can't train: %v", name, err)
+		}
+		if acc := Accuracy(c, test); acc < 0.95 {
+			t.Fatalf("%s: accuracy is too low: %.3f", name, acc)
+		}
+
+		proba := c.PredictProba(test[0].Features)
+		var sum float64
+		for _, p := range proba {
+			sum += p
+		}
+		if len(proba) != 3 || math.Abs(sum-1) > 1e-9 {
+			t.Fatalf("%s: wrong probabilities %v", name, proba)
+		}
+
+		var buf bytes.Buffer
+		if err := c.Save(&buf); err != nil {
+			t.Fatalf("%s: can't save: %v", name, err)
+		}
+		var loaded Classifier
+		switch c.(type) {
+		case *AveragedPerceptron:
+			loaded = NewAveragedPerceptron()
+		case *LogisticRegression:
+			loaded = NewLogisticRegression()
+		}
+		if err := loaded.Load(&buf); err != nil {
+			t.Fatalf("%s: can't load: %v", name, err)
+		}
+		for _, sample := range test {
+			if loaded.Predict(sample.Features) != c.Predict(sample.Features) {
+				t.Fatalf("%s: loaded model predicts differently", name)
+			}
+		}
+	}
+
+	// L1 makes noise weights zero
+	zeros := 0
+	for i := 0; i < 20; i++ {
+		if l1.Weight(fmt.Sprintf("noise=%d", i), "c0") == 0 {
+			zeros++
+		}
+	}
+	if zeros < 10 || l1.Weight("signal=0", "c0") <= 0 {
+		t.Fatalf("L1 regularization doesn't produce sparse weights: %d zeros", zeros)
+	}
+
+	// model of another kind is rejected
+	var buf bytes.Buffer
+	classifiers["perceptron"].Save(&buf)
+	if err := NewLogisticRegression().Load(&buf); err == nil {
+		t.Fatalf("model of another kind should be rejected")
+	}
+}
+
+func TestLBFGS(t *testing.T) {
+	// Rosenbrock function
+	f := func(x, grad []float64) float64 {
+		a, b := 1-x[0], x[1]-x[0]*x[0]
+		grad[0] = -2*a - 400*x[0]*b
+		grad[1] = 200 * b
+		return a*a + 100*b*b
+	}
+	x := []float64{-1.2, 1}
+	value, _ := minimizeLBFGS(f, x, lbfgsOptions{maxIter: 200, tolerance: 1e-12})
+	if value > 1e-6 || math.Abs(x[0]-1) > 1e-3 || math.Abs(x[1]-1) > 1e-3 {
+		t.Fatalf("wrong minimum %v (value %g)", x, value)
+	}
+}
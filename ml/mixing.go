@@ -0,0 +1,119 @@
+package ml
+
+import "github.com/korobool/nlp4go/core"
+
+// Fork returns copy of model for parallel training. Fork has its own copy
+// of Features, so forks don't contend for the vocabulary lock, features
+// added by forks are added to model by Mix. Classes are shared with model
+// (vocabulary is safe for concurrent use). Fork starts from current weights
+// of model without training state, model must not be changed until Mix
+func (ap *AveragedPerceptron) Fork() *AveragedPerceptron {
+	fork := &AveragedPerceptron{
+		Features:  ap.Features.Clone(),
+		Classes:   ap.Classes,
+		TieBreak:  ap.TieBreak,
+		Rounds:    ap.Rounds,
+		Precision: ap.Precision,
+		weights:   make([][]float64, len(ap.weights)),
+	}
+	for feat, weights := range ap.weights {
+		fork.weights[feat] = append([]float64(nil), weights...)
+	}
+	return fork
+}
+
+// Mix replaces weights of model by mean of weights of its forks trained
+// in parallel (iterative parameter mixing, McDonald et al., 2010).
+// Updates of all forks are added to training state of model, so averaged
+// model is the average of weights visited by all forks. Forks are
+// renumbered by features of model and must not be used after mixing
+func (ap *AveragedPerceptron) Mix(forks []*AveragedPerceptron) {
+	if len(forks) == 0 {
+		return
+	}
+	// counts of features before forks were trained
+	counts := make([]int, ap.Features.Len())
+	for id := range counts {
+		counts[id] = ap.Features.Count(int32(id))
+	}
+	for _, fork := range forks {
+		fork.mergeFeatures(ap.Features, counts)
+	}
+	size := len(ap.weights)
+	updates := 0
+	for _, fork := range forks {
+		if len(fork.weights) > size {
+			size = len(fork.weights)
+		}
+		updates += fork.i
+	}
+
+	for feat := 0; feat < size; feat++ {
+		classes := 0
+		for _, fork := range forks {
+			if feat < len(fork.weights) && len(fork.weights[feat]) > classes {
+				classes = len(fork.weights[feat])
+			}
+		}
+		if classes == 0 {
+			continue
+		}
+		ap.grow(int32(feat), int32(classes-1))
+		weights, totals, tstamps := ap.weights[feat], ap.totals[feat], ap.tstamps[feat]
+		for class := range weights {
+			// weights are constant between mixing, so totals are up to date
+			totals[class] += float64(ap.i-tstamps[class]) * weights[class]
+			tstamps[class] = ap.i + updates
+			weights[class] = 0
+		}
+		for _, fork := range forks {
+			if feat >= len(fork.weights) {
+				continue
+			}
+			for class, weight := range fork.weights[feat] {
+				// weights not updated by fork are constant since forking
+				total, tstamp := 0.0, 0
+				if feat < len(fork.totals) && class < len(fork.totals[feat]) {
+					total, tstamp = fork.totals[feat][class], fork.tstamps[feat][class]
+				}
+				totals[class] += total + float64(fork.i-tstamp)*weight
+				weights[class] += weight / float64(len(forks))
+			}
+		}
+	}
+	ap.i += updates
+}
+
+// Adds features of fork (and counts of their updates since forking) to
+// `features` of model and moves weights of fork to IDs of model
+func (ap *AveragedPerceptron) mergeFeatures(features *core.Vocabulary, counts []int) {
+	if ap.Features == features {
+		return
+	}
+	// features of model keep their IDs in fork, new ones follow them
+	mapping := make([]int32, ap.Features.Len())
+	for id := range mapping {
+		word, count := ap.Features.Word(int32(id)), ap.Features.Count(int32(id))
+		if id < len(counts) {
+			mapping[id] = int32(id)
+			if count > counts[id] {
+				features.AddCount(word, count-counts[id])
+			}
+			continue
+		}
+		mapping[id] = features.AddCount(word, count)
+	}
+
+	size := features.Len()
+	weights, totals, tstamps := make([][]float64, size), make([][]float64, size), make([][]int, size)
+	for id, row := range ap.weights {
+		if id >= len(mapping) || mapping[id] < 0 {
+			continue
+		}
+		weights[mapping[id]] = row
+		if id < len(ap.totals) {
+			totals[mapping[id]], tstamps[mapping[id]] = ap.totals[id], ap.tstamps[id]
+		}
+	}
+	ap.Features, ap.weights, ap.totals, ap.tstamps = features, weights, totals, tstamps
+}
@@ -0,0 +1,94 @@
+package ml
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestMixSingleFork(t *testing.T) {
+	examples := makeExamples(300, 30, 5, 3)
+	train := func(ap *AveragedPerceptron, examples []example) {
+		for _, ex := range examples {
+			ap.UpdateFeatures(ex.class, ap.PredictFeatures(ex.features), ex.features)
+		}
+	}
+	serial := NewAveragedPerceptron()
+	train(serial, examples)
+	serial.AverageWeights()
+
+	// mixing of one fork per epoch is serial training
+	ap := NewAveragedPerceptron()
+	for _, part := range [][]example{examples[:100], examples[100:]} {
+		fork := ap.Fork()
+		train(fork, part)
+		ap.Mix([]*AveragedPerceptron{fork})
+	}
+	if ap.Updates() != len(examples) {
+		t.Fatalf("wrong count of updates: %d", ap.Updates())
+	}
+	ap.AverageWeights()
+	if !reflect.DeepEqual(ap.ExportWeights(), serial.ExportWeights()) {
+		t.Fatal("mixed model differs from serially trained one")
+	}
+	// features added by forks keep order and counts of serial training
+	if !reflect.DeepEqual(ap.Features.Words(), serial.Features.Words()) {
+		t.Fatal("features of mixed model differ from serially trained ones")
+	}
+	for id := 0; id < serial.Features.Len(); id++ {
+		if ap.Features.Count(int32(id)) != serial.Features.Count(int32(id)) {
+			t.Fatalf("wrong count of feature %s", serial.Features.Word(int32(id)))
+		}
+	}
+}
+
+func TestMixParallel(t *testing.T) {
+	train := makeExamples(2000, 50, 5, 4)
+	test := makeExamples(500, 50, 5, 5)
+	ap := NewAveragedPerceptron()
+	for epoch := 0; epoch < 5; epoch++ {
+		forks := []*AveragedPerceptron{ap.Fork(), ap.Fork(), ap.Fork(), ap.Fork()}
+		var wg sync.WaitGroup
+		for w, fork := range forks {
+			wg.Add(1)
+			go func(w int, fork *AveragedPerceptron) {
+				defer wg.Done()
+				for i := w; i < len(train); i += len(forks) {
+					ex := train[i]
+					fork.UpdateFeatures(ex.class, fork.PredictFeatures(ex.features), ex.features)
+				}
+			}(w, fork)
+		}
+		wg.Wait()
+
+		forkWeights := make([]map[string]map[string]float64, len(forks))
+		for i, fork := range forks {
+			forkWeights[i] = fork.ExportWeights()
+		}
+		ap.Mix(forks)
+		for _, weights := range forkWeights {
+			for feat := range weights {
+				if _, ok := ap.Features.ID(feat); !ok {
+					t.Fatalf("feature %s of fork is lost", feat)
+				}
+			}
+		}
+		// weights are mean of weights of forks
+		for feat, classes := range ap.ExportWeights() {
+			for class, weight := range classes {
+				var sum float64
+				for _, weights := range forkWeights {
+					sum += weights[feat][class]
+				}
+				if diff := weight - sum/float64(len(forks)); diff > 1e-9 || diff < -1e-9 {
+					t.Fatalf("wrong mixed weight of %s %s: %f", feat, class, weight)
+				}
+			}
+		}
+	}
+	ap.AverageWeights()
+	serial := trainDense(train, 5)
+	if acc, serialAcc := accuracy(ap.PredictFeatures, test), accuracy(serial.PredictFeatures, test); acc < serialAcc-0.05 {
+		t.Fatalf("accuracy of mixed model is too low: %.3f (serial %.3f)", acc, serialAcc)
+	}
+}
@@ -0,0 +1,284 @@
+package ml
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sync"
+	"time"
+)
+
+// Model file layout:
+//
+//	magic         8 bytes  "NLP4GOM\x00"
+//	version       uint16   format version (big endian)
+//	header size   uint32
+//	header        JSON encoded ModelHeader
+//	header CRC32  uint32   IEEE checksum of header bytes
+//	payload       gob encoded model, compressed as specified in header
+//
+// Header is readable without decoding of payload, payload is verified
+// by SHA-256 checksum stored in header
+const ModelFormatVersion = 1
+
+var modelMagic = [8]byte{'N', 'L', 'P', '4', 'G', 'O', 'M', 0}
+
+// Compression of model payload
+type Compression string
+
+const (
+	CompressionNone Compression = ""
+	CompressionGzip Compression = "gzip"
+	// Zstandard isn't supported out of the box, codec has to be
+	// registered by RegisterCompression
+	CompressionZstd Compression = "zstd"
+)
+
+// Codec of payload compression
+type CompressionCodec struct {
+	NewWriter func(w io.Writer) (io.WriteCloser, error)
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	codecsMutex sync.RWMutex
+	codecs      = map[Compression]CompressionCodec{
+		CompressionGzip: {
+			NewWriter: func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil },
+			NewReader: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+		},
+	}
+)
+
+// RegisterCompression adds compression codec (e.g. zstd codec from
+// third-party package) to be used for model files
+func RegisterCompression(compression Compression, codec CompressionCodec) {
+	codecsMutex.Lock()
+	codecs[compression] = codec
+	codecsMutex.Unlock()
+}
+
+func getCodec(compression Compression) (CompressionCodec, error) {
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+
+	codec, ok := codecs[compression]
+	if !ok {
+		return codec, &UnsupportedCompressionError{compression}
+	}
+	return codec, nil
+}
+
+// Information about training of model
+type TrainingInfo struct {
+	Created   time.Time         `json:"created"`
+	Rounds    int               `json:"rounds,omitempty"`
+	Sentences int               `json:"sentences,omitempty"`
+	Tokens    int               `json:"tokens,omitempty"`
+	Params    map[string]string `json:"params,omitempty"`
+}
+
+// Self-describing header of model file
+type ModelHeader struct {
+	// Type of model, e.g. "perceptron_tagger"
+	Kind string `json:"kind"`
+	// Set of classes (tags) predicted by model
+	Tagset []string `json:"tagset,omitempty"`
+	// ID of feature extraction templates model was trained with
+	FeatureTemplate string       `json:"feature_template,omitempty"`
+	Training        TrainingInfo `json:"training"`
+	// Fields filled by WriteModel
+	Compression Compression `json:"compression,omitempty"`
+	PayloadSize int64       `json:"payload_size"`
+	Checksum    string      `json:"checksum"`
+}
+
+// Returned if file doesn't start with model file magic
+var ErrNotModelFile = errors.New("not a model file")
+
+// Returned if format version of file isn't supported
+type VersionError struct {
+	Version   int
+	Supported int
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("model format version %d is not supported (supported version is %d)", e.Version, e.Supported)
+}
+
+// Returned if model file is damaged or truncated
+type CorruptedModelError struct {
+	Reason string
+}
+
+func (e *CorruptedModelError) Error() string {
+	return "corrupted model file: " + e.Reason
+}
+
+// Returned if model can't be used by reader (different kind of model,
+// feature templates, etc.)
+type IncompatibleModelError struct {
+	Field    string
+	Expected string
+	Got      string
+}
+
+func (e *IncompatibleModelError) Error() string {
+	return fmt.Sprintf("incompatible model: %s is %q, expected %q", e.Field, e.Got, e.Expected)
+}
+
+// Returned if model file is compressed by unknown codec
+type UnsupportedCompressionError struct {
+	Compression Compression
+}
+
+func (e *UnsupportedCompressionError) Error() string {
+	return fmt.Sprintf("unsupported model compression %q", string(e.Compression))
+}
+
+// IsModelFile reports whether `r` starts with model file magic
+func IsModelFile(r *bufio.Reader) bool {
+	magic, err := r.Peek(len(modelMagic))
+	return err == nil && bytes.Equal(magic, modelMagic[:])
+}
+
+// WriteModel writes `header` and gob encoded `payload` to `w`
+func WriteModel(w io.Writer, header ModelHeader, payload interface{}) error {
+	var raw bytes.Buffer
+	if err := gob.NewEncoder(&raw).Encode(payload); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(raw.Bytes())
+	header.Checksum = hex.EncodeToString(sum[:])
+	header.PayloadSize = int64(raw.Len())
+
+	data := raw.Bytes()
+	if header.Compression != CompressionNone {
+		codec, err := getCodec(header.Compression)
+		if err != nil {
+			return err
+		}
+		var compressed bytes.Buffer
+		cw, err := codec.NewWriter(&compressed)
+		if err != nil {
+			return err
+		}
+		if _, err := cw.Write(data); err != nil {
+			return err
+		}
+		if err := cw.Close(); err != nil {
+			return err
+		}
+		data = compressed.Bytes()
+	}
+
+	headerData, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	bw.Write(modelMagic[:])
+	binary.Write(bw, binary.BigEndian, uint16(ModelFormatVersion))
+	binary.Write(bw, binary.BigEndian, uint32(len(headerData)))
+	bw.Write(headerData)
+	binary.Write(bw, binary.BigEndian, crc32.ChecksumIEEE(headerData))
+	bw.Write(data)
+	return bw.Flush()
+}
+
+// ReadModelHeader reads and verifies magic, version and header of model file
+// `r` is left positioned at the start of payload
+func ReadModelHeader(r io.Reader) (*ModelHeader, error) {
+	var magic [8]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil || magic != modelMagic {
+		return nil, ErrNotModelFile
+	}
+	var version uint16
+	if err := binary.Read(r, binary.BigEndian, &version); err != nil {
+		return nil, &CorruptedModelError{"truncated header"}
+	}
+	if version != ModelFormatVersion {
+		return nil, &VersionError{int(version), ModelFormatVersion}
+	}
+	var size, crc uint32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return nil, &CorruptedModelError{"truncated header"}
+	}
+	// header is small, huge size means damaged file
+	if size > 64<<20 {
+		return nil, &CorruptedModelError{"invalid header size"}
+	}
+	headerData := make([]byte, size)
+	if _, err := io.ReadFull(r, headerData); err != nil {
+		return nil, &CorruptedModelError{"truncated header"}
+	}
+	if err := binary.Read(r, binary.BigEndian, &crc); err != nil {
+		return nil, &CorruptedModelError{"truncated header"}
+	}
+	if crc != crc32.ChecksumIEEE(headerData) {
+		return nil, &CorruptedModelError{"header checksum mismatch"}
+	}
+	header := &ModelHeader{}
+	if err := json.Unmarshal(headerData, header); err != nil {
+		return nil, &CorruptedModelError{"invalid header: " + err.Error()}
+	}
+	return header, nil
+}
+
+// ReadModel reads model file written by WriteModel, verifies its checksum
+// and decodes payload into `payload` (pointer to value of the same type
+// that was written)
+func ReadModel(r io.Reader, payload interface{}) (*ModelHeader, error) {
+	header, err := ReadModelHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if header.Compression != CompressionNone {
+		codec, err := getCodec(header.Compression)
+		if err != nil {
+			return nil, err
+		}
+		cr, err := codec.NewReader(r)
+		if err != nil {
+			return nil, &CorruptedModelError{"invalid compressed payload: " + err.Error()}
+		}
+		defer cr.Close()
+		r = cr
+	}
+	data, err := io.ReadAll(io.LimitReader(r, header.PayloadSize+1))
+	if err != nil {
+		return nil, &CorruptedModelError{"can't read payload: " + err.Error()}
+	}
+	if int64(len(data)) != header.PayloadSize {
+		return nil, &CorruptedModelError{"payload size mismatch"}
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != header.Checksum {
+		return nil, &CorruptedModelError{"payload checksum mismatch"}
+	}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(payload); err != nil {
+		return nil, &CorruptedModelError{"can't decode payload: " + err.Error()}
+	}
+	return header, nil
+}
+
+// Reads model file of `kind` into `payload`
+func loadModel(r io.Reader, kind string, payload interface{}) error {
+	header, err := ReadModel(r, payload)
+	if err != nil {
+		return err
+	}
+	if header.Kind != kind {
+		return &IncompatibleModelError{Field: "kind", Expected: kind, Got: header.Kind}
+	}
+	return nil
+}
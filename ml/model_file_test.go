@@ -0,0 +1,88 @@
+package ml
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+)
+
+type testPayload struct {
+	Name    string
+	Weights []float64
+}
+
+func writeTestModel(t *testing.T, compression Compression) []byte {
+	var buf bytes.Buffer
+	header := ModelHeader{
+		Kind:            "test",
+		Tagset:          []string{"A", "B"},
+		FeatureTemplate: "test-v1",
+		Training:        TrainingInfo{Rounds: 3, Params: map[string]string{"lr": "0.1"}},
+		Compression:     compression,
+	}
+	payload := testPayload{"model", []float64{1, 2, 3}}
+	if err := WriteModel(&buf, header, &payload); err != nil {
+		t.Fatalf("can't write model: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestModelFile(t *testing.T) {
+	for _, compression := range []Compression{CompressionNone, CompressionGzip} {
+		data := writeTestModel(t, compression)
+		if !IsModelFile(bufio.NewReader(bytes.NewReader(data))) {
+			t.Fatalf("model file isn't recognized")
+		}
+
+		var payload testPayload
+		header, err := ReadModel(bytes.NewReader(data), &payload)
+		if err != nil {
+			t.Fatalf("can't read model: %v", err)
+		}
+		if header.Kind != "test" || header.FeatureTemplate != "test-v1" || len(header.Tagset) != 2 ||
+			header.Training.Rounds != 3 || header.Training.Params["lr"] != "0.1" || header.Compression != compression {
+			t.Fatalf("wrong header: %+v", header)
+		}
+		if payload.Name != "model" || len(payload.Weights) != 3 {
+			t.Fatalf("wrong payload: %+v", payload)
+		}
+	}
+}
+
+func TestModelFileErrors(t *testing.T) {
+	var payload testPayload
+
+	if _, err := ReadModel(bytes.NewReader([]byte("something else")), &payload); err != ErrNotModelFile {
+		t.Fatalf("expected ErrNotModelFile, got %v", err)
+	}
+
+	data := writeTestModel(t, CompressionNone)
+	future := append([]byte{}, data...)
+	future[9] = 2
+	var versionErr *VersionError
+	if _, err := ReadModel(bytes.NewReader(future), &payload); !errors.As(err, &versionErr) || versionErr.Version != 2 {
+		t.Fatalf("expected VersionError, got %v", err)
+	}
+
+	var corruptedErr *CorruptedModelError
+	damaged := append([]byte{}, data...)
+	damaged[len(damaged)-5] ^= 0xff
+	if _, err := ReadModel(bytes.NewReader(damaged), &payload); !errors.As(err, &corruptedErr) {
+		t.Fatalf("expected CorruptedModelError for damaged payload, got %v", err)
+	}
+	damaged = append([]byte{}, data...)
+	damaged[20] ^= 0xff
+	if _, err := ReadModel(bytes.NewReader(damaged), &payload); !errors.As(err, &corruptedErr) {
+		t.Fatalf("expected CorruptedModelError for damaged header, got %v", err)
+	}
+	if _, err := ReadModel(bytes.NewReader(data[:len(data)-10]), &payload); !errors.As(err, &corruptedErr) {
+		t.Fatalf("expected CorruptedModelError for truncated file, got %v", err)
+	}
+
+	var compressionErr *UnsupportedCompressionError
+	err := WriteModel(&bytes.Buffer{}, ModelHeader{Compression: CompressionZstd}, &payload)
+	if !errors.As(err, &compressionErr) {
+		t.Fatalf("expected UnsupportedCompressionError, got %v", err)
+	}
+}
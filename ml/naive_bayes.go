@@ -0,0 +1,347 @@
+package ml
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"github.com/korobool/nlp4go/core"
+	"io"
+	"math"
+	"sort"
+)
+
+// Kind of NaiveBayes models in model file header
+const NaiveBayesKind = "naive_bayes"
+
+// Event model of NaiveBayes
+type EventModel int
+
+const (
+	// Features are counts of words, P(f|c) is share of f among all features of c
+	Multinomial EventModel = iota
+	// Features are binary (present or not), P(f|c) is share of
+	// samples of c that have f, absent features are taken into account
+	Bernoulli
+)
+
+// NaiveBayes is a multinomial or Bernoulli Naive Bayes classifier
+type NaiveBayes struct {
+	Features *core.Vocabulary
+	Classes  *core.Vocabulary
+	Model    EventModel
+	// Additive smoothing of feature probabilities (1 is Laplace smoothing)
+	Alpha float64
+	// If positive, only MaxFeatures features with the highest
+	// chi-square statistic are used
+	MaxFeatures int
+	logPrior    []float64
+	// logProb[feature][class] is log P(f|c), logNotProb is log(1 - P(f|c))
+	logProb    [][]float64
+	logNotProb [][]float64
+	// Sum of logNotProb of all features for every class (Bernoulli model)
+	logNotSum []float64
+}
+
+// Serializable state of NaiveBayes
+type naiveBayesDump struct {
+	Features   *core.Vocabulary
+	Classes    *core.Vocabulary
+	Model      EventModel
+	Alpha      float64
+	LogPrior   []float64
+	LogProb    [][]float64
+	LogNotProb [][]float64
+}
+
+// Feature with the highest ratio of probabilities between classes
+type InformativeFeature struct {
+	Feature string
+	// Class with the highest and the lowest P(f|c)
+	Positive string
+	Negative string
+	// P(f|Positive) / P(f|Negative)
+	Ratio float64
+}
+
+// Constructor creates Naive Bayes of `model` with Laplace smoothing
+func NewNaiveBayes(model EventModel) *NaiveBayes {
+	return &NaiveBayes{
+		Features: core.NewVocabulary(""),
+		Classes:  core.NewVocabulary(""),
+		Model:    model,
+		Alpha:    1.0,
+	}
+}
+
+// Train estimates probabilities from samples, values of features
+// are counts (Bernoulli model only checks if they are positive).
+// Model is trained from scratch, classes are ones of samples only
+func (nb *NaiveBayes) Train(samples []Sample) error {
+	if len(samples) == 0 {
+		return errors.New("no training samples")
+	}
+	nb.Classes = core.NewVocabulary("")
+	features := core.NewVocabulary("")
+	encoded := encodeSamples(features, nb.Classes, samples)
+	nClass := nb.Classes.Len()
+
+	classDocs := make([]float64, nClass)
+	// count of samples of class with feature and sum of feature values
+	docFreq := make([][]float64, features.Len())
+	termFreq := make([][]float64, features.Len())
+	for f := range docFreq {
+		docFreq[f] = make([]float64, nClass)
+		termFreq[f] = make([]float64, nClass)
+	}
+	for _, sample := range encoded {
+		classDocs[sample.class]++
+		for i, f := range sample.ids {
+			if sample.values[i] > 0 {
+				docFreq[f][sample.class]++
+				termFreq[f][sample.class] += sample.values[i]
+			}
+		}
+	}
+
+	selected := make([]int, features.Len())
+	for f := range selected {
+		selected[f] = f
+	}
+	if nb.MaxFeatures > 0 && nb.MaxFeatures < len(selected) {
+		chi2 := chiSquare(docFreq, classDocs)
+		sort.SliceStable(selected, func(i, j int) bool { return chi2[selected[i]] > chi2[selected[j]] })
+		selected = selected[:nb.MaxFeatures]
+		// keep original order of features
+		sort.Ints(selected)
+	}
+
+	nb.Features = core.NewVocabulary("")
+	nb.logProb = make([][]float64, len(selected))
+	nb.logNotProb = make([][]float64, len(selected))
+	classTotals := make([]float64, nClass)
+	for _, f := range selected {
+		for c, count := range termFreq[f] {
+			classTotals[c] += count
+		}
+	}
+	for i, f := range selected {
+		nb.Features.Add(features.Word(int32(f)))
+		nb.logProb[i] = make([]float64, nClass)
+		nb.logNotProb[i] = make([]float64, nClass)
+		for c := 0; c < nClass; c++ {
+			var p float64
+			if nb.Model == Bernoulli {
+				p = (docFreq[f][c] + nb.Alpha) / (classDocs[c] + 2*nb.Alpha)
+			} else {
+				p = (termFreq[f][c] + nb.Alpha) / (classTotals[c] + nb.Alpha*float64(len(selected)))
+			}
+			nb.logProb[i][c] = math.Log(p)
+			nb.logNotProb[i][c] = math.Log1p(-p)
+		}
+	}
+
+	nb.logPrior = make([]float64, nClass)
+	for c, docs := range classDocs {
+		nb.logPrior[c] = math.Log(docs / float64(len(samples)))
+	}
+	nb.updateSums()
+	return nil
+}
+
+func (nb *NaiveBayes) updateSums() {
+	nb.logNotSum = make([]float64, len(nb.logPrior))
+	for _, probs := range nb.logNotProb {
+		for c, p := range probs {
+			nb.logNotSum[c] += p
+		}
+	}
+}
+
+// Returns chi-square statistic of independence of feature presence
+// and class (max over classes) for every feature
+func chiSquare(docFreq [][]float64, classDocs []float64) []float64 {
+	var total float64
+	for _, docs := range classDocs {
+		total += docs
+	}
+	scores := make([]float64, len(docFreq))
+	for f, freqs := range docFreq {
+		var withFeature float64
+		for _, freq := range freqs {
+			withFeature += freq
+		}
+		for c, a := range freqs {
+			// a - class with feature, b - other classes with feature,
+			// cc - class without feature, d - other classes without feature
+			b := withFeature - a
+			cc := classDocs[c] - a
+			d := total - withFeature - cc
+			denom := (a + cc) * (b + d) * (a + b) * (cc + d)
+			if denom == 0 {
+				continue
+			}
+			chi2 := total * (a*d - b*cc) * (a*d - b*cc) / denom
+			scores[f] = math.Max(scores[f], chi2)
+		}
+	}
+	return scores
+}
+
+// ChiSquare returns chi-square statistic of features of samples
+// (max over classes), it's used for feature selection by Train
+func ChiSquare(samples []Sample) map[string]float64 {
+	features, classes := core.NewVocabulary(""), core.NewVocabulary("")
+	encoded := encodeSamples(features, classes, samples)
+	classDocs := make([]float64, classes.Len())
+	docFreq := make([][]float64, features.Len())
+	for f := range docFreq {
+		docFreq[f] = make([]float64, classes.Len())
+	}
+	for _, sample := range encoded {
+		classDocs[sample.class]++
+		for i, f := range sample.ids {
+			if sample.values[i] > 0 {
+				docFreq[f][sample.class]++
+			}
+		}
+	}
+	scores := make(map[string]float64, features.Len())
+	for f, score := range chiSquare(docFreq, classDocs) {
+		scores[features.Word(int32(f))] = score
+	}
+	return scores
+}
+
+// Returns log of joint probabilities of classes and features
+func (nb *NaiveBayes) logScores(features Features) []float64 {
+	scores := make([]float64, len(nb.logPrior))
+	copy(scores, nb.logPrior)
+	if nb.Model == Bernoulli {
+		for c := range scores {
+			scores[c] += nb.logNotSum[c]
+		}
+	}
+	vec := encodeFeatures(nb.Features, features, false)
+	for i, f := range vec.ids {
+		if vec.values[i] <= 0 {
+			continue
+		}
+		for c := range scores {
+			if nb.Model == Bernoulli {
+				scores[c] += nb.logProb[f][c] - nb.logNotProb[f][c]
+			} else {
+				scores[c] += vec.values[i] * nb.logProb[f][c]
+			}
+		}
+	}
+	return scores
+}
+
+// Predict returns the most probable class
+func (nb *NaiveBayes) Predict(features Features) string {
+	scores := nb.logScores(features)
+	best := -1
+	for c, score := range scores {
+		if best < 0 || score > scores[best] {
+			best = c
+		}
+	}
+	return nb.Classes.Word(int32(best))
+}
+
+// PredictProba returns posterior probabilities of classes
+func (nb *NaiveBayes) PredictProba(features Features) map[string]float64 {
+	return probaMap(nb.Classes, nb.logScores(features))
+}
+
+// MostInformativeFeatures returns `n` features with the highest ratio
+// of P(f|c) between the most and the least likely classes
+func (nb *NaiveBayes) MostInformativeFeatures(n int) []InformativeFeature {
+	informative := make([]InformativeFeature, 0, len(nb.logProb))
+	for f, probs := range nb.logProb {
+		if len(probs) < 2 {
+			break
+		}
+		maxC, minC := 0, 0
+		for c, p := range probs {
+			if p > probs[maxC] {
+				maxC = c
+			}
+			if p < probs[minC] {
+				minC = c
+			}
+		}
+		informative = append(informative, InformativeFeature{
+			Feature:  nb.Features.Word(int32(f)),
+			Positive: nb.Classes.Word(int32(maxC)),
+			Negative: nb.Classes.Word(int32(minC)),
+			Ratio:    math.Exp(probs[maxC] - probs[minC]),
+		})
+	}
+	sort.SliceStable(informative, func(i, j int) bool {
+		if informative[i].Ratio != informative[j].Ratio {
+			return informative[i].Ratio > informative[j].Ratio
+		}
+		return informative[i].Feature < informative[j].Feature
+	})
+	if n >= 0 && n < len(informative) {
+		informative = informative[:n]
+	}
+	return informative
+}
+
+// ShowMostInformativeFeatures writes report of MostInformativeFeatures
+// in format of NLTK
+func (nb *NaiveBayes) ShowMostInformativeFeatures(w io.Writer, n int) error {
+	if _, err := fmt.Fprintln(w, "Most Informative Features"); err != nil {
+		return err
+	}
+	for _, f := range nb.MostInformativeFeatures(n) {
+		_, err := fmt.Fprintf(w, "%30s %8s : %-8s = %8.1f : 1.0\n", f.Feature, f.Positive, f.Negative, f.Ratio)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder interface
+func (nb *NaiveBayes) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	dump := naiveBayesDump{nb.Features, nb.Classes, nb.Model, nb.Alpha, nb.logPrior, nb.logProb, nb.logNotProb}
+	if err := gob.NewEncoder(&buf).Encode(dump); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder interface
+func (nb *NaiveBayes) GobDecode(data []byte) error {
+	var dump naiveBayesDump
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&dump); err != nil {
+		return err
+	}
+	if dump.Features == nil || dump.Classes == nil {
+		return errors.New("naive bayes has no vocabularies")
+	}
+	if len(dump.LogProb) != dump.Features.Len() || len(dump.LogNotProb) != len(dump.LogProb) ||
+		len(dump.LogPrior) != dump.Classes.Len() {
+		return errors.New("naive bayes probabilities don't match vocabularies")
+	}
+	nb.Features, nb.Classes, nb.Model, nb.Alpha = dump.Features, dump.Classes, dump.Model, dump.Alpha
+	nb.logPrior, nb.logProb, nb.logNotProb = dump.LogPrior, dump.LogProb, dump.LogNotProb
+	nb.updateSums()
+	return nil
+}
+
+// Save writes model to `w` in model file format
+func (nb *NaiveBayes) Save(w io.Writer) error {
+	header := ModelHeader{Kind: NaiveBayesKind, Tagset: nb.Classes.Words()}
+	return WriteModel(w, header, nb)
+}
+
+// Load reads model written by Save
+func (nb *NaiveBayes) Load(r io.Reader) error {
+	return loadModel(r, NaiveBayesKind, nb)
+}
@@ -0,0 +1,136 @@
+package ml
+
+import (
+	"bytes"
+	"github.com/korobool/nlp4go/tokenize"
+	"math"
+	"strings"
+	"testing"
+)
+
+var ticketDocs = []Document{
+	{"cannot login to my account password reset does not work", "account"},
+	{"password expired and login fails", "account"},
+	{"how to change account email and password", "account"},
+	{"two factor login code never arrives", "account"},
+	{"account locked after wrong password", "account"},
+	{"invoice shows wrong amount charged twice", "billing"},
+	{"refund for double charge on my card", "billing"},
+	{"how to download invoice for last month", "billing"},
+	{"card payment declined but money charged", "billing"},
+	{"change billing address on invoice", "billing"},
+	{"app crashes when opening settings page", "bug"},
+	{"error 500 when saving the report", "bug"},
+	{"page does not load and shows error", "bug"},
+	{"export to csv crashes the app", "bug"},
+	{"button does nothing after update error", "bug"},
+}
+
+var ticketTests = []Document{
+	{"forgot password cannot login", "account"},
+	{"charged twice please refund", "billing"},
+	{"app shows error and crashes", "bug"},
+	{"need invoice for my payment", "billing"},
+}
+
+func TestNaiveBayes(t *testing.T) {
+	tokenizer := tokenize.NewSplitTokenizer(" ")
+	for _, model := range []EventModel{Multinomial, Bernoulli} {
+		nb := NewNaiveBayes(model)
+		tc := NewTextClassifier(tokenizer, nb)
+		if err := tc.Train(ticketDocs); err != nil {
+			t.Fatal(err)
+		}
+		if acc := tc.Accuracy(ticketTests); acc != 1 {
+			t.Fatalf("model %d: wrong accuracy %.2f", model, acc)
+		}
+
+		proba := tc.Proba("password login")
+		var sum float64
+		for _, p := range proba {
+			sum += p
+		}
+		if math.Abs(sum-1) > 1e-9 || proba["account"] < 0.5 {
+			t.Fatalf("model %d: wrong probabilities %v", model, proba)
+		}
+
+		var buf bytes.Buffer
+		if err := nb.Save(&buf); err != nil {
+			t.Fatal(err)
+		}
+		loaded := NewNaiveBayes(Multinomial)
+		if err := loaded.Load(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if loaded.Model != model || !mapsAlmostEqual(loaded.PredictProba(tc.Features("error invoice")), nb.PredictProba(tc.Features("error invoice"))) {
+			t.Fatalf("model %d: loaded model differs", model)
+		}
+	}
+}
+
+func TestNaiveBayesRetrain(t *testing.T) {
+	tc := NewTextClassifier(tokenize.NewSplitTokenizer(" "), NewNaiveBayes(Multinomial))
+	if err := tc.Train(ticketDocs); err != nil {
+		t.Fatal(err)
+	}
+	// classes of previous training are dropped
+	if err := tc.Train(ticketDocs[:10]); err != nil {
+		t.Fatal(err)
+	}
+	proba := tc.Proba("app crashes")
+	if _, ok := proba["bug"]; ok || len(proba) != 2 {
+		t.Fatalf("wrong classes of retrained model: %v", proba)
+	}
+	for class, p := range proba {
+		if math.IsNaN(p) || p <= 0 {
+			t.Fatalf("wrong probability of %s: %v", class, proba)
+		}
+	}
+}
+
+func mapsAlmostEqual(a, b map[string]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if math.Abs(b[k]-v) > 1e-12 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestNaiveBayesFeatureSelection(t *testing.T) {
+	tc := NewTextClassifier(tokenize.NewSplitTokenizer(" "), NewNaiveBayes(Multinomial))
+	samples := tc.Samples(ticketDocs)
+
+	chi2 := ChiSquare(samples)
+	if chi2["password"] <= chi2["and"] || chi2["invoice"] <= chi2["my"] {
+		t.Fatalf("wrong chi-square statistic: %v", chi2)
+	}
+
+	nb := tc.Classifier.(*NaiveBayes)
+	nb.MaxFeatures = 10
+	if err := nb.Train(samples); err != nil {
+		t.Fatal(err)
+	}
+	if nb.Features.Len() != 10 {
+		t.Fatalf("expected 10 features, got %d", nb.Features.Len())
+	}
+	if _, ok := nb.Features.ID("password"); !ok {
+		t.Fatalf("informative feature wasn't selected: %v", nb.Features.Words())
+	}
+	if acc := tc.Accuracy(ticketTests); acc < 0.75 {
+		t.Fatalf("wrong accuracy with selected features %.2f", acc)
+	}
+
+	informative := nb.MostInformativeFeatures(3)
+	if len(informative) != 3 || informative[0].Ratio < informative[2].Ratio || informative[0].Ratio <= 1 {
+		t.Fatalf("wrong informative features: %v", informative)
+	}
+	var buf bytes.Buffer
+	nb.ShowMostInformativeFeatures(&buf, 3)
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 4 || !strings.Contains(lines[1], informative[0].Feature) {
+		t.Fatalf("wrong report:\n%s", buf.String())
+	}
+}
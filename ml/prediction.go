@@ -0,0 +1,74 @@
+package ml
+
+import "sort"
+
+// Score of class for some input
+type ClassScore struct {
+	Class string  `json:"class"`
+	Score float64 `json:"score"`
+	// Softmax-normalized score, it's rather a measure of confidence than
+	// calibrated probability, since perceptron scores aren't log-probabilities
+	Prob float64 `json:"prob"`
+}
+
+// Scores of classes ordered by score (the best class first)
+type Prediction struct {
+	Classes []ClassScore `json:"classes"`
+	// Difference between scores of the first and the second class
+	// (score of the first class if there is only one)
+	Margin float64 `json:"margin"`
+}
+
+// Returns the best class or empty string if there are no classes
+func (p *Prediction) Best() string {
+	if len(p.Classes) == 0 {
+		return ""
+	}
+	return p.Classes[0].Class
+}
+
+// Returns pseudo-probability of the best class
+func (p *Prediction) Confidence() float64 {
+	if len(p.Classes) == 0 {
+		return 0
+	}
+	return p.Classes[0].Prob
+}
+
+// Returns labels of classes in order of scores
+func (p *Prediction) Labels() []string {
+	labels := make([]string, len(p.Classes))
+	for i, class := range p.Classes {
+		labels[i] = class.Class
+	}
+	return labels
+}
+
+// Makes prediction from scores indexed by class IDs
+// Only `k` best classes are kept if `k` is positive
+func newPrediction(scores []float64, labels func(int32) string, tieBreak TieBreak, k int) *Prediction {
+	probs := Softmax(scores)
+	classes := make([]ClassScore, len(scores))
+	for i, score := range scores {
+		classes[i] = ClassScore{labels(int32(i)), score, probs[i]}
+	}
+	// ties are ordered by label, the same way as in Predict
+	sort.Slice(classes, func(i, j int) bool {
+		if classes[i].Score != classes[j].Score {
+			return classes[i].Score > classes[j].Score
+		}
+		return tieBreak.wins(classes[i].Class, classes[j].Class)
+	})
+
+	p := &Prediction{Classes: classes}
+	switch {
+	case len(classes) == 1:
+		p.Margin = classes[0].Score
+	case len(classes) > 1:
+		p.Margin = classes[0].Score - classes[1].Score
+	}
+	if k > 0 && k < len(classes) {
+		p.Classes = classes[:k:k]
+	}
+	return p
+}
@@ -0,0 +1,50 @@
+package ml
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSoftmax(t *testing.T) {
+	probs := Softmax([]float64{1000, 1000, 0})
+	if math.Abs(probs[0]-0.5) > 1e-9 || math.Abs(probs[1]-0.5) > 1e-9 || probs[2] > 1e-9 {
+		t.Fatalf("wrong softmax: %v", probs)
+	}
+	if len(Softmax(nil)) != 0 {
+		t.Fatalf("softmax of empty slice should be empty")
+	}
+}
+
+func TestPredictScores(t *testing.T) {
+	ap := NewAveragedPerceptron()
+	ap.UpdateFeatures("A", "B", []string{"x"})
+	ap.UpdateFeatures("C", "A", []string{"y"})
+	ap.UpdateFeatures("C", "C", []string{"y"})
+
+	p := ap.PredictScores([]string{"x", "y"})
+	if len(p.Classes) != 3 {
+		t.Fatalf("expected scores of all classes, got %v", p.Classes)
+	}
+	// x: A+1 B-1, y: C+1 A-1
+	if p.Best() != "C" || p.Classes[1].Class != "A" || p.Classes[2].Class != "B" {
+		t.Fatalf("wrong order of classes: %v", p.Labels())
+	}
+	if p.Margin != 1 {
+		t.Fatalf("wrong margin: %v", p.Margin)
+	}
+	var sum float64
+	for _, class := range p.Classes {
+		sum += class.Prob
+	}
+	if math.Abs(sum-1) > 1e-9 || p.Confidence() <= p.Classes[1].Prob {
+		t.Fatalf("wrong probabilities: %v", p.Classes)
+	}
+	if p.Best() != ap.PredictFeatures([]string{"x", "y"}) {
+		t.Fatalf("PredictScores and PredictFeatures differ")
+	}
+
+	top := ap.PredictTopK([]string{"x", "y"}, 2)
+	if len(top.Classes) != 2 || top.Margin != p.Margin || top.Classes[0] != p.Classes[0] {
+		t.Fatalf("wrong top-k prediction: %v", top)
+	}
+}
@@ -0,0 +1,166 @@
+package ml
+
+import "math"
+
+// Precision of weights of saved AveragedPerceptron
+type Precision int
+
+const (
+	PrecisionFloat64 Precision = iota
+	PrecisionFloat32
+	// Weights of every feature are int8 multiplied by scale of feature
+	// (max absolute weight of feature / 127)
+	PrecisionInt8
+)
+
+func (p Precision) String() string {
+	switch p {
+	case PrecisionFloat32:
+		return "float32"
+	case PrecisionInt8:
+		return "int8"
+	default:
+		return "float64"
+	}
+}
+
+// Prune removes weights with absolute value less than `threshold`
+// and features that took part in less than `minCount` updates (counts
+// of Features, see UpdateFeatures). Features left without weights are
+// removed from Features. Model must be averaged, returns count of
+// removed features
+func (ap *AveragedPerceptron) Prune(threshold float64, minCount int) int {
+	for feat, weights := range ap.weights {
+		var last int
+		for class, weight := range weights {
+			if math.Abs(weight) < threshold {
+				weights[class] = 0
+			}
+			if weights[class] != 0 {
+				last = class + 1
+			}
+		}
+		ap.weights[feat] = weights[:last:last]
+	}
+
+	before := ap.Features.Len()
+	mapping := ap.Features.Filter(func(id int32, word string, count int) bool {
+		return int(id) < len(ap.weights) && len(ap.weights[id]) > 0 && count >= minCount
+	})
+	weights := make([][]float64, ap.Features.Len())
+	for id, newID := range mapping {
+		if newID >= 0 && id < len(ap.weights) {
+			weights[newID] = ap.weights[id]
+		}
+	}
+	ap.weights, ap.totals, ap.tstamps = weights, nil, nil
+	return before - ap.Features.Len()
+}
+
+// Quantize rounds weights to values representable with precision `p`,
+// so accuracy of model can be checked before saving, and sets precision
+// of saved weights
+func (ap *AveragedPerceptron) Quantize(p Precision) {
+	ap.Precision = p
+	for _, weights := range ap.weights {
+		switch p {
+		case PrecisionFloat32:
+			for class, weight := range weights {
+				weights[class] = float64(float32(weight))
+			}
+		case PrecisionInt8:
+			scale := int8Scale(weights)
+			for class, weight := range weights {
+				weights[class] = float64(quantizeInt8(weight, scale)) * scale
+			}
+		}
+	}
+}
+
+// Size returns count of features with weights and count of non-zero weights
+func (ap *AveragedPerceptron) Size() (features, weights int) {
+	for _, vector := range ap.weights {
+		nonZero := 0
+		for _, weight := range vector {
+			if weight != 0 {
+				nonZero++
+			}
+		}
+		if nonZero > 0 {
+			features++
+			weights += nonZero
+		}
+	}
+	return
+}
+
+// Returns scale of int8 weights of feature, it's saved as float32,
+// so it's rounded to float32 here as well
+func int8Scale(weights []float64) float64 {
+	var max float64
+	for _, weight := range weights {
+		max = math.Max(max, math.Abs(weight))
+	}
+	if max == 0 {
+		return 1
+	}
+	return float64(float32(max / 127))
+}
+
+func quantizeInt8(weight, scale float64) int8 {
+	return int8(math.Max(-127, math.Min(127, math.Round(weight/scale))))
+}
+
+// Fills fields of dump with weights of precision of model
+func (ap *AveragedPerceptron) encodeWeights(dump *averagedPerceptronDump) {
+	dump.Precision = ap.Precision
+	switch ap.Precision {
+	case PrecisionFloat32:
+		dump.Weights32 = make([][]float32, len(ap.weights))
+		for feat, weights := range ap.weights {
+			dump.Weights32[feat] = make([]float32, len(weights))
+			for class, weight := range weights {
+				dump.Weights32[feat][class] = float32(weight)
+			}
+		}
+	case PrecisionInt8:
+		dump.Weights8 = make([][]byte, len(ap.weights))
+		dump.Scales = make([]float32, len(ap.weights))
+		for feat, weights := range ap.weights {
+			scale := int8Scale(weights)
+			dump.Scales[feat] = float32(scale)
+			dump.Weights8[feat] = make([]byte, len(weights))
+			for class, weight := range weights {
+				dump.Weights8[feat][class] = byte(quantizeInt8(weight, scale))
+			}
+		}
+	default:
+		dump.Weights = ap.weights
+	}
+}
+
+// Returns weights of dump converted to float64
+func (dump *averagedPerceptronDump) decodeWeights() [][]float64 {
+	switch dump.Precision {
+	case PrecisionFloat32:
+		weights := make([][]float64, len(dump.Weights32))
+		for feat, vector := range dump.Weights32 {
+			weights[feat] = make([]float64, len(vector))
+			for class, weight := range vector {
+				weights[feat][class] = float64(weight)
+			}
+		}
+		return weights
+	case PrecisionInt8:
+		weights := make([][]float64, len(dump.Weights8))
+		for feat, vector := range dump.Weights8 {
+			weights[feat] = make([]float64, len(vector))
+			for class, weight := range vector {
+				weights[feat][class] = float64(int8(weight)) * float64(dump.Scales[feat])
+			}
+		}
+		return weights
+	default:
+		return dump.Weights
+	}
+}
@@ -0,0 +1,54 @@
+package ml
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPruneAndQuantize(t *testing.T) {
+	train := makeClassificationSamples(600, 1)
+	test := makeClassificationSamples(300, 2)
+
+	var sizes []int
+	for _, precision := range []Precision{PrecisionFloat64, PrecisionFloat32, PrecisionInt8} {
+		ap := NewAveragedPerceptron()
+		if err := ap.Train(train); err != nil {
+			t.Fatal(err)
+		}
+		features, weights := ap.Size()
+		removed := ap.Prune(0.5, 2)
+		// vocabulary also has features that were never updated
+		if pruned, prunedWeights := ap.Size(); removed == 0 || pruned >= features || prunedWeights >= weights ||
+			ap.Features.Len() != pruned {
+			t.Fatalf("%s: wrong pruning: %d features, %d weights -> %d removed, %d weights",
+				precision, features, weights, removed, prunedWeights)
+		}
+		ap.Quantize(precision)
+		if acc := Accuracy(ap, test); acc < 0.9 {
+			t.Fatalf("%s: accuracy of pruned model is too low: %.3f", precision, acc)
+		}
+
+		var buf bytes.Buffer
+		if err := ap.Save(&buf); err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, buf.Len())
+		loaded := &AveragedPerceptron{}
+		if err := loaded.Load(&buf); err != nil {
+			t.Fatalf("%s: can't load: %v", precision, err)
+		}
+		if loaded.Precision != precision {
+			t.Fatalf("%s: wrong precision of loaded model: %s", precision, loaded.Precision)
+		}
+		for feat, weights := range ap.weights {
+			for class, weight := range weights {
+				if loaded.weights[feat][class] != weight {
+					t.Fatalf("%s: saved weight differs: %f != %f", precision, loaded.weights[feat][class], weight)
+				}
+			}
+		}
+	}
+	if !(sizes[0] > sizes[1] && sizes[1] > sizes[2]) {
+		t.Fatalf("model size doesn't decrease with precision: %v", sizes)
+	}
+}
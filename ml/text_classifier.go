@@ -0,0 +1,86 @@
+package ml
+
+import (
+	"github.com/korobool/nlp4go/tokenize"
+	"strings"
+)
+
+// Labeled text
+type Document struct {
+	Text  string
+	Class string
+}
+
+// TextClassifier classifies texts by bag of words of their tokens
+// using any Classifier (e.g. NaiveBayes or LogisticRegression)
+type TextClassifier struct {
+	Tokenizer  tokenize.Tokenizer
+	Classifier Classifier
+	// Convert words to lower case
+	Lowercase bool
+}
+
+// Constructor creates text classifier with lower case bag of words
+func NewTextClassifier(tokenizer tokenize.Tokenizer, classifier Classifier) *TextClassifier {
+	return &TextClassifier{
+		Tokenizer:  tokenizer,
+		Classifier: classifier,
+		Lowercase:  true,
+	}
+}
+
+// Features returns counts of words of text
+func (tc *TextClassifier) Features(text string) Features {
+	features := Features{}
+	for _, token := range tc.Tokenizer.Tokenize(text) {
+		word := token.Word
+		if tc.Lowercase {
+			word = strings.ToLower(word)
+		}
+		features[word] += 1
+	}
+	return features
+}
+
+// Returns training samples made of documents
+func (tc *TextClassifier) Samples(docs []Document) []Sample {
+	samples := make([]Sample, len(docs))
+	for i, doc := range docs {
+		samples[i] = Sample{tc.Features(doc.Text), doc.Class}
+	}
+	return samples
+}
+
+// Train trains classifier on documents
+func (tc *TextClassifier) Train(docs []Document) error {
+	return tc.Classifier.Train(tc.Samples(docs))
+}
+
+// Classify returns the best class of text
+func (tc *TextClassifier) Classify(text string) string {
+	return tc.Classifier.Predict(tc.Features(text))
+}
+
+// Proba returns probabilities of classes of text
+func (tc *TextClassifier) Proba(text string) map[string]float64 {
+	return tc.Classifier.PredictProba(tc.Features(text))
+}
+
+// Accuracy returns share of documents classified correctly
+func (tc *TextClassifier) Accuracy(docs []Document) float64 {
+	return Accuracy(tc.Classifier, tc.Samples(docs))
+}
+
+// Accuracy returns share of samples classified correctly
+func Accuracy(c Classifier, samples []Sample) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	correct := 0
+	for _, sample := range samples {
+		if c.Predict(sample.Features) == sample.Class {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(samples))
+}
@@ -18,3 +18,27 @@ func Round(val float64, roundOn float64, places int) (newVal float64) {
 	newVal = round / pow
 	return
 }
+
+// Softmax returns exp(x[i]) / sum(exp(x)) for all elements of `x`
+func Softmax(x []float64) []float64 {
+	res := make([]float64, len(x))
+	if len(x) == 0 {
+		return res
+	}
+	// subtract max for numerical stability
+	max := x[0]
+	for _, v := range x[1:] {
+		if v > max {
+			max = v
+		}
+	}
+	var sum float64
+	for i, v := range x {
+		res[i] = math.Exp(v - max)
+		sum += res[i]
+	}
+	for i := range res {
+		res[i] /= sum
+	}
+	return res
+}
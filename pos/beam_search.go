@@ -0,0 +1,176 @@
+package pos
+
+import (
+	"github.com/korobool/nlp4go/ml"
+	"sort"
+)
+
+// Tags of sentence with total score given by model
+type TagSequence struct {
+	Tags  []string
+	Score float64
+}
+
+// Hypothesis of beam search, tags are linked in reverse order
+type beamNode struct {
+	parent *beamNode
+	tag    string
+	// nil if tag was taken from TagMap
+	features []string
+	score    float64
+	// hypothesis is a prefix of gold tags (beam training only)
+	gold bool
+}
+
+// Returns tag of the previous word and the word before it
+func (n *beamNode) history(start []string) (string, string) {
+	prev, prev2 := start[0], start[1]
+	if n != nil {
+		prev = n.tag
+		if n.parent != nil {
+			prev2 = n.parent.tag
+		} else {
+			prev2 = start[0]
+		}
+	}
+	return prev, prev2
+}
+
+func (n *beamNode) tags(length int) []string {
+	tags := make([]string, length)
+	for i := length - 1; i >= 0; i-- {
+		tags[i] = n.tag
+		n = n.parent
+	}
+	return tags
+}
+
+// Returns decisions of model (tags not taken from TagMap) in order of words
+func (n *beamNode) decisions() []ml.Decision {
+	var decisions []ml.Decision
+	for ; n != nil; n = n.parent {
+		if n.features != nil {
+			decisions = append(decisions, ml.Decision{Class: n.tag, Features: n.features})
+		}
+	}
+	for i, j := 0, len(decisions)-1; i < j; i, j = i+1, j-1 {
+		decisions[i], decisions[j] = decisions[j], decisions[i]
+	}
+	return decisions
+}
+
+// Runs beam search over tags of words keeping `width` best hypotheses.
+// If `gold` tags are given, search stops as soon as gold hypothesis falls
+// out of beam (early update), then the returned beam isn't complete and
+// the gold hypothesis is returned as well
+func (t *PerceptronTagger) beamSearch(words []string, width int, gold []string) ([]*beamNode, *beamNode) {
+
+	context := t.makeContext(words)
+
+	classes := t.Model.Classes.Words()
+	beam := []*beamNode{nil}
+	var goldNode *beamNode
+	for i, word := range words {
+		tag, known := t.TagMap[word]
+		candidates := make([]*beamNode, 0, len(beam)*len(classes))
+		goldNode = nil
+		for _, node := range beam {
+			isGold := gold != nil && (node == nil || node.gold)
+			score := 0.0
+			if node != nil {
+				score = node.score
+			}
+			if known {
+				child := &beamNode{node, tag, nil, score, isGold}
+				candidates = append(candidates, child)
+				if isGold {
+					goldNode = child
+				}
+				continue
+			}
+			prev, prev2 := node.history(t.START_TOK)
+			features := t.getFeatures(i, context, prev, prev2)
+			scores := t.Model.ScoresIDs(t.Model.FeatureIDs(features, false))
+			for class, classScore := range scores {
+				child := &beamNode{node, classes[class], features, score + classScore, isGold && classes[class] == gold[i]}
+				candidates = append(candidates, child)
+				if child.gold {
+					goldNode = child
+				}
+			}
+		}
+		sort.SliceStable(candidates, func(a, b int) bool { return candidates[a].score > candidates[b].score })
+		if len(candidates) > width {
+			candidates = candidates[:width]
+		}
+		beam = candidates
+
+		if gold != nil {
+			inBeam := false
+			for _, node := range beam {
+				inBeam = inBeam || node.gold
+			}
+			if !inBeam {
+				return beam, goldNode
+			}
+		}
+	}
+	return beam, goldNode
+}
+
+// Tags words by beam search
+func (t *PerceptronTagger) beamTags(words []string) []string {
+	if len(words) == 0 {
+		return []string{}
+	}
+	beam, _ := t.beamSearch(words, t.BeamWidth, nil)
+	if len(beam) == 0 {
+		// model has no classes, words are left untagged as by greedy tagging
+		return make([]string, len(words))
+	}
+	return beam[0].tags(len(words))
+}
+
+// TagNBest returns up to `n` best tag sequences of already tokenized
+// sentence found by beam search (beam is at least `n` wide), no sequences
+// if model has no classes
+func (t *PerceptronTagger) TagNBest(words []string, n int) []TagSequence {
+	if len(words) == 0 || n < 1 {
+		return []TagSequence{}
+	}
+	width := t.BeamWidth
+	if width < n {
+		width = n
+	}
+	beam, _ := t.beamSearch(words, width, nil)
+	if len(beam) > n {
+		beam = beam[:n]
+	}
+	sequences := make([]TagSequence, len(beam))
+	for i, node := range beam {
+		sequences[i] = TagSequence{node.tags(len(words)), node.score}
+	}
+	return sequences
+}
+
+// Trains model on sentence by structured perceptron with early update:
+// decisions of gold prefix and of the best hypothesis are updated as soon
+// as gold prefix falls out of beam (or at the end of sentence).
+// Returns count of wrong tags of the best hypothesis
+func (t *PerceptronTagger) trainBeam(sentence WordsTags) int {
+	beam, gold := t.beamSearch(sentence.Words, t.BeamWidth, sentence.Tags)
+	if len(beam) == 0 || gold == nil || beam[0] == gold {
+		t.Model.UpdateStructured(nil, nil)
+		return 0
+	}
+	t.Model.UpdateStructured(gold.decisions(), beam[0].decisions())
+
+	// both hypotheses end at the word of early update
+	errors := 0
+	for node, goldNode := beam[0], gold; node != nil; node, goldNode = node.parent, goldNode.parent {
+		if node.tag != goldNode.tag {
+			errors++
+		}
+	}
+	return errors
+}
@@ -0,0 +1,85 @@
+package pos
+
+import (
+	"reflect"
+	"testing"
+)
+
+var beamSentences = makeSentences(
+	"the/DT dog/NN is/VBZ running/VBG ./.",
+	"a/DT cat/NN is/VBZ sleeping/VBG ./.",
+	"the/DT cats/NNS are/VBP eating/VBG fish/NN ./.",
+	"they/PRP fish/VBP in/IN the/DT lake/NN ./.",
+	"the/DT dog/NN was/VBD barking/VBG loudly/RB ./.",
+	"a/DT man/NN walked/VBD slowly/RB ./.",
+	"John/NNP is/VBZ a/DT man/NN ./.",
+	"we/PRP walk/VBP the/DT dog/NN ./.",
+)
+
+func TestBeamSearchMatchesGreedy(t *testing.T) {
+	tagger, _ := NewPerceptronTagger(TaggerConfig{})
+	tagger.Train(append([]WordsTags(nil), beamSentences...), 5, nil)
+
+	for _, sent := range beamSentences {
+		greedy := tagger.TagWords(sent.Words)
+		beam, _ := tagger.beamSearch(sent.Words, 1, nil)
+		if tags := beam[0].tags(len(sent.Words)); !reflect.DeepEqual(tags, greedy) {
+			t.Fatalf("beam of width 1 differs from greedy tagging: %v != %v", tags, greedy)
+		}
+	}
+}
+
+func TestBeamTraining(t *testing.T) {
+	tagger, _ := NewPerceptronTagger(TaggerConfig{BeamWidth: 4})
+	tagger.Train(append([]WordsTags(nil), beamSentences...), 10, nil)
+	if tagger.Training.Params["beam_width"] != "4" {
+		t.Fatalf("beam width isn't saved in training info: %v", tagger.Training.Params)
+	}
+
+	var correct, total int
+	for _, sent := range beamSentences {
+		for i, tag := range tagger.TagWords(sent.Words) {
+			if tag == sent.Tags[i] {
+				correct++
+			}
+			total++
+		}
+	}
+	if acc := float64(correct) / float64(total); acc < 0.85 {
+		t.Fatalf("accuracy on training sentences is too low: %.3f", acc)
+	}
+
+	words := beamSentences[3].Words
+	nbest := tagger.TagNBest(words, 3)
+	if len(nbest) != 3 || !reflect.DeepEqual(nbest[0].Tags, tagger.TagWords(words)) {
+		t.Fatalf("wrong n-best sequences: %v", nbest)
+	}
+	for i := 1; i < len(nbest); i++ {
+		if nbest[i].Score > nbest[i-1].Score || reflect.DeepEqual(nbest[i].Tags, nbest[i-1].Tags) {
+			t.Fatalf("n-best sequences aren't sorted or unique: %v", nbest)
+		}
+	}
+	if len(tagger.TagNBest(nil, 3)) != 0 {
+		t.Fatal("n-best of empty sentence isn't empty")
+	}
+}
+
+func TestBeamSearchUntrained(t *testing.T) {
+	tagger, _ := NewPerceptronTagger(TaggerConfig{BeamWidth: 3})
+	words := []string{"the", "dog", "runs"}
+	if tags := tagger.TagWords(words); !reflect.DeepEqual(tags, []string{"", "", ""}) {
+		t.Fatalf("wrong tags of untrained model: %v", tags)
+	}
+	if nbest := tagger.TagNBest(words, 2); len(nbest) != 0 {
+		t.Fatalf("wrong n-best sequences of untrained model: %v", nbest)
+	}
+	// words of tag dictionary are tagged without classes
+	tagger.TagMap["the"] = "DT"
+	if tags := tagger.TagWords(words[:1]); !reflect.DeepEqual(tags, []string{"DT"}) {
+		t.Fatalf("wrong tags of tag dictionary words: %v", tags)
+	}
+	greedy, _ := NewPerceptronTagger(TaggerConfig{})
+	if tags := greedy.TagWords(words); !reflect.DeepEqual(tags, []string{"", "", ""}) {
+		t.Fatalf("beam and greedy tags of untrained model differ: %v", tags)
+	}
+}
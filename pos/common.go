@@ -19,4 +19,10 @@ type TaggerConfig struct {
 	Tokenizer tokenize.Tokenizer
 	LoadModel bool
 	ModelPath string
+	// Width of beam search of taggers that support it, 0 or 1 means
+	// greedy tagging by PerceptronTagger and exact search by HMMTagger
+	BeamWidth int
+	// Feature templates of PerceptronTagger (and CRFTagger) training,
+	// built-in features are used if it's nil
+	Templates *FeatureTemplates
 }
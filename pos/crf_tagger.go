@@ -0,0 +1,172 @@
+package pos
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/korobool/nlp4go/ml"
+	"github.com/korobool/nlp4go/tokenize"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Kind of CRFTagger models in model file header
+const CRFTaggerKind = "crf_tagger"
+
+// CRFTagger tags sentences with linear-chain CRF, observation features
+// are the same as features of PerceptronTagger without tag history
+type CRFTagger struct {
+	tokenizer tokenize.Tokenizer
+	ModelPath string
+	Model     *ml.CRF
+	// Extractor of features, only its FeatureTemplate and Templates matter
+	Features *PerceptronTagger
+	// Compression of saved model
+	Compression ml.Compression
+	// Filled by Train and LoadModel
+	Training ml.TrainingInfo
+}
+
+// Payload of CRFTagger model file
+type crfTaggerDump struct {
+	Model *ml.CRF
+	// Features of FeatureTemplateCustom
+	Templates *FeatureTemplates
+}
+
+func NewCRFTagger(config TaggerConfig) (*CRFTagger, error) {
+
+	features, err := NewPerceptronTagger(TaggerConfig{Tokenizer: config.Tokenizer, Templates: config.Templates})
+	if err != nil {
+		return nil, err
+	}
+	tagger := CRFTagger{
+		tokenizer: config.Tokenizer,
+		ModelPath: "crf_tagger_model.bin",
+		Model:     ml.NewCRF(),
+		Features:  features,
+	}
+	if config.ModelPath != "" {
+		tagger.ModelPath = config.ModelPath
+	}
+	if config.LoadModel {
+		if err := tagger.LoadModel(tagger.ModelPath); err != nil {
+			return nil, err
+		}
+	}
+	return &tagger, nil
+}
+
+func (t *CRFTagger) Tag(sentence string) ([]*tokenize.Token, error) {
+
+	tokens := t.tokenizer.Tokenize(sentence)
+	words := make([]string, len(tokens))
+	for i, token := range tokens {
+		words[i] = token.Word
+	}
+	for i, tag := range t.TagWords(words) {
+		tokens[i].PosTag = tag
+	}
+	return tokens, nil
+}
+
+// TagWords tags already tokenized sentence by Viterbi decoding,
+// untrained model returns empty tags
+func (t *CRFTagger) TagWords(words []string) []string {
+	if t.Model.Labels.Len() == 0 {
+		return make([]string, len(words))
+	}
+	return t.Model.Tag(t.Features.ObservationFeatures(words))
+}
+
+// Marginals returns probability of every tag for every word
+func (t *CRFTagger) Marginals(words []string) []map[string]float64 {
+	return t.Model.Marginals(t.Features.ObservationFeatures(words))
+}
+
+// Train trains CRF from scratch, `iterations` is max count of
+// L-BFGS iterations (or SGD epochs if Model.Optimizer is ml.OptimizerSGD)
+func (t *CRFTagger) Train(sentences []WordsTags, iterations int, progressFn Callback) error {
+
+	sequences := make([]ml.LabeledSequence, len(sentences))
+	for i, sent := range sentences {
+		sequences[i] = ml.LabeledSequence{
+			Features: t.Features.ObservationFeatures(sent.Words),
+			Labels:   sent.Tags,
+		}
+	}
+	t.Model.Iterations = iterations
+	if progressFn != nil {
+		t.Model.Progress = func(iter int, loss float64) {
+			progressFn(iter, iterations)
+		}
+		defer func() { t.Model.Progress = nil }()
+	}
+
+	t.Training = ml.TrainingInfo{
+		Created:   time.Now().UTC(),
+		Rounds:    iterations,
+		Sentences: len(sentences),
+		Params: map[string]string{
+			"l2":        strconv.FormatFloat(t.Model.L2, 'g', -1, 64),
+			"optimizer": strconv.Itoa(int(t.Model.Optimizer)),
+		},
+	}
+	for _, sent := range sentences {
+		t.Training.Tokens += len(sent.Words)
+	}
+	return t.Model.Train(sequences)
+}
+
+// LoadModel reads model saved by SaveModel
+func (t *CRFTagger) LoadModel(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var dump crfTaggerDump
+	header, err := ml.ReadModel(bufio.NewReader(file), &dump)
+	if err != nil {
+		return err
+	}
+	if header.Kind != CRFTaggerKind {
+		return &ml.IncompatibleModelError{Field: "kind", Expected: CRFTaggerKind, Got: header.Kind}
+	}
+	if err := checkFeatureTemplate(header.FeatureTemplate, dump.Templates); err != nil {
+		return err
+	}
+	if dump.Model == nil {
+		return &ml.CorruptedModelError{Reason: "incomplete crf tagger model"}
+	}
+	t.Model = dump.Model
+	t.Compression = header.Compression
+	t.Training = header.Training
+	t.Features.FeatureTemplate = header.FeatureTemplate
+	t.Features.Templates = dump.Templates
+	return nil
+}
+
+// SaveModel writes model to ModelPath
+func (t *CRFTagger) SaveModel() error {
+	file, err := os.Create(t.ModelPath)
+	if err != nil {
+		return err
+	}
+	tagset := t.Model.Labels.Words()
+	sort.Strings(tagset)
+	header := ml.ModelHeader{
+		Kind:            CRFTaggerKind,
+		Tagset:          tagset,
+		FeatureTemplate: t.Features.FeatureTemplate,
+		Training:        t.Training,
+		Compression:     t.Compression,
+	}
+	if err := ml.WriteModel(file, header, &crfTaggerDump{t.Model, t.Features.customTemplates()}); err != nil {
+		file.Close()
+		return fmt.Errorf("can't save model %s: %w", t.ModelPath, err)
+	}
+	return file.Close()
+}
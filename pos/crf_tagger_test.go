@@ -0,0 +1,76 @@
+package pos
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func makeSentences(lines ...string) []WordsTags {
+	sentences := make([]WordsTags, len(lines))
+	for i, line := range lines {
+		for _, pair := range strings.Fields(line) {
+			parts := strings.SplitN(pair, "/", 2)
+			sentences[i].Words = append(sentences[i].Words, parts[0])
+			sentences[i].Tags = append(sentences[i].Tags, parts[1])
+		}
+	}
+	return sentences
+}
+
+func TestCRFTagger(t *testing.T) {
+	train := makeSentences(
+		"the/DT dog/NN runs/VBZ ./.",
+		"a/DT cat/NN sleeps/VBZ ./.",
+		"the/DT cats/NNS run/VBP fast/RB ./.",
+		"dogs/NNS bark/VBP loudly/RB ./.",
+		"the/DT run/NN was/VBD long/JJ ./.",
+		"a/DT dog/NN barks/VBZ ./.",
+	)
+	tagger, err := NewCRFTagger(TaggerConfig{ModelPath: filepath.Join(t.TempDir(), "crf.bin")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags := tagger.TagWords([]string{"the", "dog"}); !reflect.DeepEqual(tags, []string{"", ""}) {
+		t.Fatalf("wrong tags of untrained model: %v", tags)
+	}
+	if err := tagger.Train(train, 50, nil); err != nil {
+		t.Fatalf("can't train: %v", err)
+	}
+	for _, sent := range train {
+		if tags := tagger.TagWords(sent.Words); !reflect.DeepEqual(tags, sent.Tags) {
+			t.Fatalf("wrong tags of %v: %v", sent.Words, tags)
+		}
+	}
+	// "run" is a noun after determiner
+	words := []string{"the", "run", "was", "long", "."}
+	marginals := tagger.Marginals(words)
+	if marginals[1]["NN"] < 0.5 {
+		t.Fatalf("wrong marginals of 'run': %v", marginals[1])
+	}
+
+	if err := tagger.SaveModel(); err != nil {
+		t.Fatalf("can't save model: %v", err)
+	}
+	loaded, err := NewCRFTagger(TaggerConfig{ModelPath: tagger.ModelPath, LoadModel: true})
+	if err != nil {
+		t.Fatalf("can't load model: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.TagWords(words), tagger.TagWords(words)) || loaded.Training.Sentences != len(train) {
+		t.Fatal("loaded model differs from saved one")
+	}
+}
+
+func TestObservationFeatures(t *testing.T) {
+	tagger, _ := NewPerceptronTagger(TaggerConfig{})
+	sequence := tagger.ObservationFeatures([]string{"The", "dog"})
+	if len(sequence) != 2 || len(sequence[0]) != 10 {
+		t.Fatalf("wrong observation features: %v", sequence)
+	}
+	for _, feature := range sequence[1] {
+		if strings.Contains(feature, "tag") {
+			t.Fatalf("feature depends on tags: %s", feature)
+		}
+	}
+}
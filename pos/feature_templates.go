@@ -0,0 +1,269 @@
+package pos
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/korobool/nlp4go/ml"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ID of features described by PerceptronTagger.Templates
+const FeatureTemplateCustom = "custom"
+
+// Attribute of word at offset from the current word (-1 is the previous
+// word, words out of sentence are START_TOK and END_TOK).
+// Attributes are "word", "lower", "shape" (e.g. "Xxx-dd" for "Abc-12"),
+// "suffix-N", "prefix-N" (N runes) and "cluster" (see FeatureTemplates.Clusters).
+// If Normalized is set, attribute is taken from word normalized as by
+// default features (lowercased, numbers and hyphenated words replaced)
+type WordAttribute struct {
+	Offset     int    `json:"offset"`
+	Attribute  string `json:"attribute"`
+	Normalized bool   `json:"normalized,omitempty"`
+}
+
+// Template of one feature: conjunction of tags of previous words (offsets
+// -1 and -2) and attributes of words. Value of feature is its name
+// followed by tags and attributes, template without them is a bias
+type Template struct {
+	Name  string          `json:"name"`
+	Tags  []int           `json:"tags,omitempty"`
+	Words []WordAttribute `json:"words,omitempty"`
+}
+
+// FeatureTemplates describe features of PerceptronTagger, they are saved
+// in model file, so tagging uses the same features as training
+type FeatureTemplates struct {
+	Templates []Template `json:"templates"`
+	// Word clusters (e.g. Brown clusters) of "cluster" attribute, words
+	// are looked up as is and lowercased
+	Clusters map[string]string `json:"clusters,omitempty"`
+}
+
+// DefaultTemplates returns templates of default features (FeatureTemplateV2)
+func DefaultTemplates() *FeatureTemplates {
+	word := func(offset int, attribute string, normalized bool) []WordAttribute {
+		return []WordAttribute{{offset, attribute, normalized}}
+	}
+	return &FeatureTemplates{Templates: []Template{
+		{Name: "bias"},
+		{Name: "i suffix", Words: word(0, "suffix-3", false)},
+		{Name: "i pref1", Words: word(0, "prefix-1", false)},
+		{Name: "i-1 tag", Tags: []int{-1}},
+		{Name: "i-2 tag", Tags: []int{-2}},
+		{Name: "i tag+i-2 tag", Tags: []int{-1, -2}},
+		{Name: "i word", Words: word(0, "word", true)},
+		{Name: "i-1 tag+i word", Tags: []int{-1}, Words: word(0, "word", true)},
+		{Name: "i-1 word", Words: word(-1, "word", true)},
+		{Name: "i-1 suffix", Words: word(-1, "suffix-3", true)},
+		{Name: "i-2 word", Words: word(-2, "word", true)},
+		{Name: "i+1 word", Words: word(1, "word", true)},
+		{Name: "i+1 suffix", Words: word(1, "suffix-3", true)},
+		{Name: "i+2 word", Words: word(2, "word", true)},
+	}}
+}
+
+// LoadFeatureTemplates reads templates from JSON file
+func LoadFeatureTemplates(filename string) (*FeatureTemplates, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var templates FeatureTemplates
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("can't parse feature templates %s: %w", filename, err)
+	}
+	if err := templates.Validate(); err != nil {
+		return nil, err
+	}
+	return &templates, nil
+}
+
+// Validate checks that names of templates are unique and attributes are known
+func (ft *FeatureTemplates) Validate() error {
+	if len(ft.Templates) == 0 {
+		return fmt.Errorf("no feature templates")
+	}
+	names := make(map[string]bool, len(ft.Templates))
+	for _, template := range ft.Templates {
+		if template.Name == "" || names[template.Name] {
+			return fmt.Errorf("feature template has empty or duplicate name %q", template.Name)
+		}
+		names[template.Name] = true
+		for _, offset := range template.Tags {
+			if offset != -1 && offset != -2 {
+				return fmt.Errorf("feature template %q: tag offset must be -1 or -2, got %d", template.Name, offset)
+			}
+		}
+		for _, word := range template.Words {
+			if _, _, err := parseAttribute(word.Attribute); err != nil {
+				return fmt.Errorf("feature template %q: %w", template.Name, err)
+			}
+			if word.Attribute == "cluster" && len(ft.Clusters) == 0 {
+				return fmt.Errorf("feature template %q: no word clusters", template.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Splits attribute into name and length of suffix or prefix
+func parseAttribute(attribute string) (string, int, error) {
+	switch attribute {
+	case "word", "lower", "shape", "cluster":
+		return attribute, 0, nil
+	}
+	for _, name := range []string{"suffix", "prefix"} {
+		if strings.HasPrefix(attribute, name+"-") {
+			n, err := strconv.Atoi(attribute[len(name)+1:])
+			if err != nil || n < 1 {
+				return "", 0, fmt.Errorf("wrong length of %s: %q", name, attribute)
+			}
+			return name, n, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unknown attribute %q", attribute)
+}
+
+// Returns features of word `i` of sentence, `prev` and `prev2` are tags
+// of previous words
+func (ft *FeatureTemplates) features(i int, context sentenceContext, prev, prev2 string) []string {
+	features := make([]string, 0, len(ft.Templates))
+	var value strings.Builder
+	for _, template := range ft.Templates {
+		value.Reset()
+		value.WriteString(template.Name)
+		for _, offset := range template.Tags {
+			value.WriteByte(' ')
+			if offset == -1 {
+				value.WriteString(prev)
+			} else {
+				value.WriteString(prev2)
+			}
+		}
+		for _, word := range template.Words {
+			value.WriteByte(' ')
+			value.WriteString(ft.attribute(context.at(i+word.Offset, word.Normalized), word.Attribute))
+		}
+		features = append(features, value.String())
+	}
+	return features
+}
+
+// Returns features of templates that don't depend on tags
+func (ft *FeatureTemplates) observations() *FeatureTemplates {
+	observations := &FeatureTemplates{Clusters: ft.Clusters}
+	for _, template := range ft.Templates {
+		if len(template.Tags) == 0 {
+			observations.Templates = append(observations.Templates, template)
+		}
+	}
+	return observations
+}
+
+func (ft *FeatureTemplates) attribute(word, attribute string) string {
+	name, n, _ := parseAttribute(attribute)
+	switch name {
+	case "lower":
+		return strings.ToLower(word)
+	case "shape":
+		return wordShape(word)
+	case "suffix":
+		return StrSuffix(word, n)
+	case "prefix":
+		if runes := []rune(word); len(runes) > n {
+			return string(runes[:n])
+		}
+		return word
+	case "cluster":
+		if cluster, ok := ft.Clusters[word]; ok {
+			return cluster
+		}
+		if cluster, ok := ft.Clusters[strings.ToLower(word)]; ok {
+			return cluster
+		}
+		return "-NONE-"
+	}
+	return word
+}
+
+// Returns shape of word: upper case letters are replaced by "X", lower
+// case ones by "x", digits by "d", repeats are collapsed
+func wordShape(word string) string {
+	var shape []rune
+	for _, char := range word {
+		switch {
+		case unicode.IsUpper(char):
+			char = 'X'
+		case unicode.IsLetter(char):
+			char = 'x'
+		case unicode.IsDigit(char):
+			char = 'd'
+		}
+		if len(shape) == 0 || shape[len(shape)-1] != char {
+			shape = append(shape, char)
+		}
+	}
+	return string(shape)
+}
+
+// Words of sentence padded by START_TOK and END_TOK as is and normalized
+type sentenceContext struct {
+	words      []string
+	normalized []string
+	// length of START_TOK
+	start int
+}
+
+func (t *PerceptronTagger) makeContext(words []string) sentenceContext {
+	context := sentenceContext{
+		words:      make([]string, 0, len(words)+4),
+		normalized: make([]string, 0, len(words)+4),
+		start:      len(t.START_TOK),
+	}
+	context.words = append(context.words, t.START_TOK...)
+	context.normalized = append(context.normalized, t.START_TOK...)
+	for _, word := range words {
+		context.words = append(context.words, word)
+		context.normalized = append(context.normalized, t.normalize(word, []rune(word)))
+	}
+	context.words = append(context.words, t.END_TOK...)
+	context.normalized = append(context.normalized, t.END_TOK...)
+	return context
+}
+
+// Returns word `i` of sentence, words beyond padding are the outermost
+// START_TOK and END_TOK
+func (c sentenceContext) at(i int, normalized bool) string {
+	words := c.words
+	if normalized {
+		words = c.normalized
+	}
+	i += c.start
+	if i < 0 {
+		i = 0
+	} else if i >= len(words) {
+		i = len(words) - 1
+	}
+	return words[i]
+}
+
+// Checks ID of features of loaded model, custom features require templates
+func checkFeatureTemplate(id string, templates *FeatureTemplates) error {
+	switch id {
+	case FeatureTemplateV1, FeatureTemplateV2:
+		return nil
+	case FeatureTemplateCustom:
+		if templates == nil {
+			return &ml.CorruptedModelError{Reason: "model has no feature templates"}
+		}
+		if err := templates.Validate(); err != nil {
+			return &ml.CorruptedModelError{Reason: err.Error()}
+		}
+		return nil
+	}
+	return &ml.IncompatibleModelError{Field: "feature template", Expected: DefaultFeatureTemplate, Got: id}
+}
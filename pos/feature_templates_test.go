@@ -0,0 +1,123 @@
+package pos
+
+import (
+	"bytes"
+	"errors"
+	"github.com/korobool/nlp4go/ml"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultTemplates(t *testing.T) {
+	tagger, _ := NewPerceptronTagger(TaggerConfig{})
+	custom, _ := NewPerceptronTagger(TaggerConfig{Templates: DefaultTemplates()})
+	words := []string{"In", "1990", "well-known", "Ёжики", "ran", "42", "-", "laps", "."}
+	context := tagger.makeContext(words)
+	for i := range words {
+		expected := tagger.getFeatures(i, context, "DT", "NN")
+		if features := custom.getFeatures(i, context, "DT", "NN"); !reflect.DeepEqual(features, expected) {
+			t.Fatalf("features of default templates differ:\n%v\n%v", features, expected)
+		}
+	}
+	if !reflect.DeepEqual(custom.ObservationFeatures(words), tagger.ObservationFeatures(words)) {
+		t.Fatal("observation features of default templates differ")
+	}
+}
+
+func TestFeatureTemplates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templates.json")
+	config := `{
+		"templates": [
+			{"name": "bias"},
+			{"name": "shape", "words": [{"offset": 0, "attribute": "shape"}]},
+			{"name": "suffix4", "words": [{"offset": 0, "attribute": "suffix-4"}]},
+			{"name": "word", "words": [{"offset": 0, "attribute": "word", "normalized": true}]},
+			{"name": "tag+next", "tags": [-1], "words": [{"offset": 1, "attribute": "lower"}]},
+			{"name": "cluster", "words": [{"offset": -3, "attribute": "cluster"}, {"offset": 0, "attribute": "prefix-2"}]}
+		],
+		"clusters": {"the": "0110", "dog": "1010"}
+	}`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	templates, err := LoadFeatureTemplates(path)
+	if err != nil {
+		t.Fatalf("can't load templates: %v", err)
+	}
+
+	tagger, err := NewPerceptronTagger(TaggerConfig{Templates: templates})
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := []string{"The", "Dog", "is", "running", "."}
+	features := tagger.getFeatures(1, tagger.makeContext(words), "DT", "-START-")
+	expected := []string{"bias", "shape Xx", "suffix4 Dog", "word dog", "tag+next DT is", "cluster -NONE- Do"}
+	if !reflect.DeepEqual(features, expected) {
+		t.Fatalf("wrong features: %v", features)
+	}
+	if observed := tagger.ObservationFeatures(words)[1]; len(observed) != 5 || strings.HasPrefix(observed[4], "tag") {
+		t.Fatalf("wrong observation features: %v", observed)
+	}
+	// clusters are looked up lowercased
+	if features := tagger.getFeatures(4, tagger.makeContext(words), "VBG", "VBZ"); features[5] != "cluster 1010 ." {
+		t.Fatalf("wrong cluster feature: %v", features)
+	}
+
+	tagger.Train(beamSentences, 10, nil)
+	var buf bytes.Buffer
+	if err := tagger.WriteModel(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, _ := NewPerceptronTagger(TaggerConfig{})
+	if err := loaded.ReadModel(&buf); err != nil {
+		t.Fatalf("can't read model: %v", err)
+	}
+	if loaded.FeatureTemplate != FeatureTemplateCustom || !reflect.DeepEqual(loaded.Templates, templates) {
+		t.Fatalf("templates aren't saved in model: %+v", loaded.Templates)
+	}
+	for _, sent := range beamSentences {
+		if !reflect.DeepEqual(loaded.TagWords(sent.Words), tagger.TagWords(sent.Words)) {
+			t.Fatal("loaded model tags differently")
+		}
+	}
+
+	// model with custom features but without templates
+	tagger.Templates = nil
+	buf.Reset()
+	tagger.WriteModel(&buf)
+	var corrupted *ml.CorruptedModelError
+	if err := loaded.ReadModel(&buf); !errors.As(err, &corrupted) {
+		t.Fatalf("expected error for model without templates, got %v", err)
+	}
+	if err := tagger.Train(beamSentences, 1, nil); err == nil {
+		t.Fatal("expected error for training without templates")
+	}
+	tagger.FeatureTemplate = "v9"
+	if err := tagger.Train(beamSentences, 1, nil); err == nil {
+		t.Fatal("expected error for training with unknown features")
+	}
+}
+
+func TestValidateTemplates(t *testing.T) {
+	for _, templates := range []FeatureTemplates{
+		{},
+		{Templates: []Template{{Name: "a"}, {Name: "a"}}},
+		{Templates: []Template{{Name: "a", Tags: []int{1}}}},
+		{Templates: []Template{{Name: "a", Words: []WordAttribute{{0, "suffix-0", false}}}}},
+		{Templates: []Template{{Name: "a", Words: []WordAttribute{{0, "color", false}}}}},
+		{Templates: []Template{{Name: "a", Words: []WordAttribute{{0, "cluster", false}}}}},
+	} {
+		if err := templates.Validate(); err == nil {
+			t.Fatalf("expected error for %+v", templates)
+		}
+	}
+	if _, err := NewPerceptronTagger(TaggerConfig{Templates: &FeatureTemplates{}}); err == nil {
+		t.Fatal("expected error for empty templates")
+	}
+	if shape := wordShape("McDonald's-99"); shape != "XxXx'x-d" {
+		t.Fatalf("wrong shape: %s", shape)
+	}
+}
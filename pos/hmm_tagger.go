@@ -0,0 +1,516 @@
+package pos
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/korobool/nlp4go/ml"
+	"github.com/korobool/nlp4go/tokenize"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+	"unicode"
+)
+
+// Kind of HMMTagger models in model file header
+const HMMTaggerKind = "hmm_tagger"
+
+// Tag of sentence boundaries in HMMTagger.Tags
+const BoundaryTag = "-BOUNDARY-"
+
+// HMMTagger is a trigram hidden Markov model tagger (TnT, Brants 2000)
+//
+// Transition probabilities are linear interpolation of unigram, bigram
+// and trigram estimates with weights found by deleted interpolation.
+// Emission probabilities of unknown words are estimated from suffixes
+// of rare words (separately for capitalized and other words)
+type HMMTagger struct {
+	tokenizer tokenize.Tokenizer
+	ModelPath string
+	// Count of best tag pairs kept for every word by Viterbi decoding,
+	// search is exact if it's not positive
+	BeamWidth int
+	// Max length of suffixes of unknown words
+	SuffixLength int
+	// Words seen at most RareThreshold times estimate suffix model
+	RareThreshold int
+	// Compression of saved model
+	Compression ml.Compression
+	// Filled by Train and LoadModel
+	Training ml.TrainingInfo
+	// Tagset, the first tag is BoundaryTag
+	Tags []string
+	// Weights of unigram, bigram and trigram probabilities
+	Lambdas [3]float64
+	counts  hmmCounts
+	// Sums of counts of bigrams by the first tag and of trigrams
+	// by the first two tags (counts of histories)
+	bigramHistory  []int
+	trigramHistory map[int64]int
+	total          int
+	// Weight of shorter suffixes in suffix model
+	theta float64
+}
+
+// Frequencies collected by Train, they're the payload of model file
+type hmmCounts struct {
+	Unigrams []int
+	Bigrams  [][]int
+	// Key is index of trigram, see trigramKey
+	Trigrams map[int64]int
+	// Word -> tag -> count
+	Lexicon map[string]map[int32]int
+	// Suffix -> counts of tags of rare words, for lowercase
+	// and capitalized words
+	Suffixes [2]map[string][]int
+}
+
+// Payload of HMMTagger model file
+type hmmTaggerDump struct {
+	Tags          []string
+	Lambdas       [3]float64
+	Counts        hmmCounts
+	SuffixLength  int
+	RareThreshold int
+}
+
+// Log probability of word given tag
+type hmmEmission struct {
+	tag     int32
+	logProb float64
+}
+
+// State of Viterbi decoding: the last two tags
+type hmmState struct {
+	prev, tag int32
+	score     float64
+	// index of previous state
+	back int
+}
+
+func NewHMMTagger(config TaggerConfig) (*HMMTagger, error) {
+
+	tagger := HMMTagger{
+		tokenizer:     config.Tokenizer,
+		ModelPath:     "hmm_tagger_model.bin",
+		BeamWidth:     config.BeamWidth,
+		SuffixLength:  10,
+		RareThreshold: 10,
+	}
+	if config.ModelPath != "" {
+		tagger.ModelPath = config.ModelPath
+	}
+	if config.LoadModel {
+		if err := tagger.LoadModel(tagger.ModelPath); err != nil {
+			return nil, err
+		}
+	}
+	return &tagger, nil
+}
+
+func (t *HMMTagger) trigramKey(t1, t2, t3 int32) int64 {
+	n := int64(len(t.Tags))
+	return (int64(t1)*n+int64(t2))*n + int64(t3)
+}
+
+// Train estimates model from tagged sentences. HMM is estimated in one pass,
+// `rounds` is ignored, so HMMTagger can replace PerceptronTagger
+func (t *HMMTagger) Train(sentences []WordsTags, rounds int, progressFn Callback) error {
+
+	tokens := 0
+	for i, sent := range sentences {
+		if len(sent.Words) != len(sent.Tags) {
+			return fmt.Errorf("sentence %d has %d words and %d tags", i, len(sent.Words), len(sent.Tags))
+		}
+		tokens += len(sent.Words)
+	}
+	if tokens == 0 {
+		return errors.New("no tagged words to train on")
+	}
+
+	tagset := map[string]struct{}{}
+	words := map[string]int{}
+	for _, sent := range sentences {
+		for i, tag := range sent.Tags {
+			tagset[tag] = struct{}{}
+			words[sent.Words[i]]++
+		}
+	}
+	t.Tags = []string{BoundaryTag}
+	for tag := range tagset {
+		t.Tags = append(t.Tags, tag)
+	}
+	sort.Strings(t.Tags[1:])
+	ids := make(map[string]int32, len(t.Tags))
+	for id, tag := range t.Tags {
+		ids[tag] = int32(id)
+	}
+
+	n := len(t.Tags)
+	t.counts = hmmCounts{
+		Unigrams: make([]int, n),
+		Bigrams:  make([][]int, n),
+		Trigrams: make(map[int64]int),
+		Lexicon:  make(map[string]map[int32]int),
+		Suffixes: [2]map[string][]int{{}, {}},
+	}
+	for i := range t.counts.Bigrams {
+		t.counts.Bigrams[i] = make([]int, n)
+	}
+
+	for cnt, sent := range sentences {
+		t1, t2 := int32(0), int32(0)
+		for i := 0; i <= len(sent.Words); i++ {
+			// boundary tag after the last word
+			t3 := int32(0)
+			if i < len(sent.Words) {
+				word := sent.Words[i]
+				t3 = ids[sent.Tags[i]]
+				if t.counts.Lexicon[word] == nil {
+					t.counts.Lexicon[word] = make(map[int32]int)
+				}
+				t.counts.Lexicon[word][t3]++
+				if words[word] <= t.RareThreshold {
+					t.addSuffixes(word, t3)
+				}
+			}
+			t.counts.Unigrams[t3]++
+			t.counts.Bigrams[t2][t3]++
+			t.counts.Trigrams[t.trigramKey(t1, t2, t3)]++
+			t1, t2 = t2, t3
+		}
+		if progressFn != nil {
+			progressFn(cnt+1, len(sentences))
+		}
+	}
+	t.update()
+	t.Lambdas = t.deletedInterpolation()
+
+	t.Training = ml.TrainingInfo{
+		Created:   time.Now().UTC(),
+		Sentences: len(sentences),
+		Params: map[string]string{
+			"suffix_length":  strconv.Itoa(t.SuffixLength),
+			"rare_threshold": strconv.Itoa(t.RareThreshold),
+		},
+	}
+	t.Training.Tokens = tokens
+	return nil
+}
+
+// Adds suffixes of rare word to suffix model
+func (t *HMMTagger) addSuffixes(word string, tag int32) {
+	runes := []rune(word)
+	suffixes := t.counts.Suffixes[capitalized(runes)]
+	for i := 0; i <= t.SuffixLength && i <= len(runes); i++ {
+		suffix := string(runes[len(runes)-i:])
+		if suffixes[suffix] == nil {
+			suffixes[suffix] = make([]int, len(t.Tags))
+		}
+		suffixes[suffix][tag]++
+	}
+}
+
+func capitalized(runes []rune) int {
+	if len(runes) > 0 && unicode.IsUpper(runes[0]) {
+		return 1
+	}
+	return 0
+}
+
+// Computes values derived from counts
+func (t *HMMTagger) update() {
+	n := len(t.Tags)
+	t.total = 0
+	for _, count := range t.counts.Unigrams {
+		t.total += count
+	}
+	t.bigramHistory = make([]int, n)
+	for t2, counts := range t.counts.Bigrams {
+		for _, count := range counts {
+			t.bigramHistory[t2] += count
+		}
+	}
+	t.trigramHistory = make(map[int64]int)
+	for key, count := range t.counts.Trigrams {
+		t.trigramHistory[key/int64(n)] += count
+	}
+
+	// standard deviation of unconditional probabilities of tags
+	var mean, variance float64
+	tags := float64(n - 1)
+	t.theta = 0
+	if t.total == 0 || tags < 2 {
+		return
+	}
+	for _, count := range t.counts.Unigrams[1:] {
+		mean += float64(count) / float64(t.total) / tags
+	}
+	for _, count := range t.counts.Unigrams[1:] {
+		d := float64(count)/float64(t.total) - mean
+		variance += d * d / (tags - 1)
+	}
+	t.theta = math.Sqrt(variance)
+}
+
+// Finds weights of interpolation by deleted interpolation
+func (t *HMMTagger) deletedInterpolation() [3]float64 {
+	var lambdas [3]float64
+	n := int64(len(t.Tags))
+	ratio := func(count, total int) float64 {
+		if total <= 1 {
+			return 0
+		}
+		return float64(count-1) / float64(total-1)
+	}
+	for key, count := range t.counts.Trigrams {
+		t2, t3 := (key/n)%n, key%n
+		c := [3]float64{
+			ratio(t.counts.Unigrams[t3], t.total),
+			ratio(t.counts.Bigrams[t2][t3], t.bigramHistory[t2]),
+			ratio(count, t.trigramHistory[key/n]),
+		}
+		best := 0
+		for i := range c {
+			if c[i] > c[best] {
+				best = i
+			}
+		}
+		lambdas[best] += float64(count)
+	}
+	var sum float64
+	for _, l := range lambdas {
+		sum += l
+	}
+	if sum == 0 {
+		return [3]float64{1, 0, 0}
+	}
+	for i := range lambdas {
+		lambdas[i] /= sum
+	}
+	return lambdas
+}
+
+// Returns log P(t3|t1,t2)
+func (t *HMMTagger) transition(t1, t2, t3 int32) float64 {
+	if t.total == 0 {
+		return math.Inf(-1)
+	}
+	p := t.Lambdas[0] * float64(t.counts.Unigrams[t3]) / float64(t.total)
+	if history := t.bigramHistory[t2]; history > 0 {
+		p += t.Lambdas[1] * float64(t.counts.Bigrams[t2][t3]) / float64(history)
+	}
+	key := t.trigramKey(t1, t2, t3)
+	if history := t.trigramHistory[key/int64(len(t.Tags))]; history > 0 {
+		p += t.Lambdas[2] * float64(t.counts.Trigrams[key]) / float64(history)
+	}
+	return math.Log(p)
+}
+
+// Returns log P(word|tag) of possible tags of word ordered by tag
+// (up to constant for unknown words)
+func (t *HMMTagger) emissions(word string) []hmmEmission {
+	var emissions []hmmEmission
+	if counts, ok := t.counts.Lexicon[word]; ok {
+		for tag, count := range counts {
+			emissions = append(emissions, hmmEmission{tag, math.Log(float64(count) / float64(t.counts.Unigrams[tag]))})
+		}
+		sort.Slice(emissions, func(i, j int) bool { return emissions[i].tag < emissions[j].tag })
+		return emissions
+	}
+
+	// P(t|suffix) smoothed by successive abstraction, P(suffix|t) ~ P(t|suffix) / P(t)
+	runes := []rune(word)
+	suffixes := t.counts.Suffixes[capitalized(runes)]
+	if len(suffixes[""]) == 0 {
+		suffixes = t.counts.Suffixes[1-capitalized(runes)]
+	}
+	prior := distribution(suffixes[""])
+	if prior == nil {
+		// no rare words at all
+		prior = distribution(t.counts.Unigrams)
+		if prior == nil {
+			// untrained model
+			return nil
+		}
+		prior[0] = 0
+	}
+	probs := append([]float64(nil), prior...)
+	for i := 1; i <= t.SuffixLength && i <= len(runes); i++ {
+		estimate := distribution(suffixes[string(runes[len(runes)-i:])])
+		if estimate == nil {
+			break
+		}
+		for tag := range probs {
+			probs[tag] = (estimate[tag] + t.theta*probs[tag]) / (1 + t.theta)
+		}
+	}
+	for tag, p := range probs {
+		if p > 0 && prior[tag] > 0 {
+			emissions = append(emissions, hmmEmission{int32(tag), math.Log(p / prior[tag])})
+		}
+	}
+	return emissions
+}
+
+// Normalizes counts, returns nil if all counts are zero
+func distribution(counts []int) []float64 {
+	var sum int
+	for _, count := range counts {
+		sum += count
+	}
+	if sum == 0 {
+		return nil
+	}
+	probs := make([]float64, len(counts))
+	for i, count := range counts {
+		probs[i] = float64(count) / float64(sum)
+	}
+	return probs
+}
+
+func (t *HMMTagger) Tag(sentence string) ([]*tokenize.Token, error) {
+
+	tokens := t.tokenizer.Tokenize(sentence)
+	words := make([]string, len(tokens))
+	for i, token := range tokens {
+		words[i] = token.Word
+	}
+	for i, tag := range t.TagWords(words) {
+		tokens[i].PosTag = tag
+	}
+	return tokens, nil
+}
+
+// TagWords tags already tokenized sentence by Viterbi (or beam) decoding
+func (t *HMMTagger) TagWords(words []string) []string {
+
+	tags := make([]string, len(words))
+	if len(words) == 0 || len(t.Tags) == 0 {
+		return tags
+	}
+	lattice := make([][]hmmState, len(words)+1)
+	states := []hmmState{{back: -1}}
+	for i, word := range words {
+		emissions := t.emissions(word)
+		next := make([]hmmState, 0, len(states)*len(emissions))
+		index := make(map[[2]int32]int)
+		for s, state := range states {
+			for _, emission := range emissions {
+				score := state.score + t.transition(state.prev, state.tag, emission.tag) + emission.logProb
+				key := [2]int32{state.tag, emission.tag}
+				if j, ok := index[key]; !ok {
+					index[key] = len(next)
+					next = append(next, hmmState{state.tag, emission.tag, score, s})
+				} else if score > next[j].score {
+					next[j].score, next[j].back = score, s
+				}
+			}
+		}
+		if t.BeamWidth > 0 && len(next) > t.BeamWidth {
+			sort.SliceStable(next, func(a, b int) bool { return next[a].score > next[b].score })
+			next = next[:t.BeamWidth]
+		}
+		if len(next) == 0 {
+			// no possible tags of word
+			return tags
+		}
+		lattice[i] = next
+		states = next
+	}
+
+	best := 0
+	bestScore := math.Inf(-1)
+	for s, state := range states {
+		if score := state.score + t.transition(state.prev, state.tag, 0); score > bestScore {
+			best, bestScore = s, score
+		}
+	}
+	for i := len(words) - 1; i >= 0; i-- {
+		state := lattice[i][best]
+		tags[i] = t.Tags[state.tag]
+		best = state.back
+	}
+	return tags
+}
+
+// LoadModel reads model saved by SaveModel
+func (t *HMMTagger) LoadModel(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return t.ReadModel(bufio.NewReader(file))
+}
+
+// ReadModel reads model written by WriteModel
+func (t *HMMTagger) ReadModel(r io.Reader) error {
+	var dump hmmTaggerDump
+	header, err := ml.ReadModel(r, &dump)
+	if err != nil {
+		return err
+	}
+	if header.Kind != HMMTaggerKind {
+		return &ml.IncompatibleModelError{Field: "kind", Expected: HMMTaggerKind, Got: header.Kind}
+	}
+	n := len(dump.Tags)
+	if n < 2 || dump.Tags[0] != BoundaryTag || len(dump.Counts.Unigrams) != n || len(dump.Counts.Bigrams) != n {
+		return &ml.CorruptedModelError{Reason: "incomplete hmm tagger model"}
+	}
+	for _, counts := range dump.Counts.Bigrams {
+		if len(counts) != n {
+			return &ml.CorruptedModelError{Reason: "hmm bigram counts don't match tagset"}
+		}
+	}
+	for _, suffixes := range dump.Counts.Suffixes {
+		for _, counts := range suffixes {
+			if len(counts) != n {
+				return &ml.CorruptedModelError{Reason: "hmm suffix counts don't match tagset"}
+			}
+		}
+	}
+
+	t.Tags, t.Lambdas, t.counts = dump.Tags, dump.Lambdas, dump.Counts
+	t.SuffixLength, t.RareThreshold = dump.SuffixLength, dump.RareThreshold
+	t.Compression = header.Compression
+	t.Training = header.Training
+	t.update()
+	return nil
+}
+
+// WriteModel writes model to `w` in versioned model file format
+func (t *HMMTagger) WriteModel(w io.Writer) error {
+	tagset := append([]string(nil), t.Tags...)
+	sort.Strings(tagset)
+	header := ml.ModelHeader{
+		Kind:        HMMTaggerKind,
+		Tagset:      tagset,
+		Training:    t.Training,
+		Compression: t.Compression,
+	}
+	dump := hmmTaggerDump{
+		Tags:          t.Tags,
+		Lambdas:       t.Lambdas,
+		Counts:        t.counts,
+		SuffixLength:  t.SuffixLength,
+		RareThreshold: t.RareThreshold,
+	}
+	return ml.WriteModel(w, header, &dump)
+}
+
+// SaveModel writes model to ModelPath
+func (t *HMMTagger) SaveModel() error {
+	file, err := os.Create(t.ModelPath)
+	if err != nil {
+		return err
+	}
+	if err := t.WriteModel(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,88 @@
+package pos
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHMMTagger(t *testing.T) {
+	train := makeSentences(
+		"the/DT dog/NN is/VBZ running/VBG ./.",
+		"a/DT cat/NN is/VBZ sleeping/VBG ./.",
+		"the/DT cats/NNS are/VBP eating/VBG fish/NN ./.",
+		"the/DT dog/NN was/VBD barking/VBG loudly/RB ./.",
+		"a/DT man/NN walked/VBD slowly/RB ./.",
+		"the/DT woman/NN talked/VBD quickly/RB ./.",
+		"John/NNP is/VBZ a/DT man/NN ./.",
+		"Mary/NNP was/VBD walking/VBG ./.",
+	)
+	tagger, err := NewHMMTagger(TaggerConfig{ModelPath: filepath.Join(t.TempDir(), "hmm.bin")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tagger.Train(train, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if sum := tagger.Lambdas[0] + tagger.Lambdas[1] + tagger.Lambdas[2]; math.Abs(sum-1) > 1e-9 {
+		t.Fatalf("lambdas don't sum to 1: %v", tagger.Lambdas)
+	}
+	for _, sent := range train {
+		if tags := tagger.TagWords(sent.Words); !reflect.DeepEqual(tags, sent.Tags) {
+			t.Fatalf("wrong tags of %v: %v", sent.Words, tags)
+		}
+	}
+
+	// unknown words are tagged by suffixes and capitalization
+	words := []string{"Peter", "is", "jumping", "happily", "."}
+	expected := []string{"NNP", "VBZ", "VBG", "RB", "."}
+	if tags := tagger.TagWords(words); !reflect.DeepEqual(tags, expected) {
+		t.Fatalf("wrong tags of unknown words: %v", tags)
+	}
+	tagger.BeamWidth = 2
+	if tags := tagger.TagWords(words); !reflect.DeepEqual(tags, expected) {
+		t.Fatalf("wrong tags of beam search: %v", tags)
+	}
+	if tags := tagger.TagWords(nil); len(tags) != 0 {
+		t.Fatalf("wrong tags of empty sentence: %v", tags)
+	}
+
+	if err := tagger.SaveModel(); err != nil {
+		t.Fatalf("can't save model: %v", err)
+	}
+	loaded, err := NewHMMTagger(TaggerConfig{ModelPath: tagger.ModelPath, LoadModel: true})
+	if err != nil {
+		t.Fatalf("can't load model: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.TagWords(words), expected) || loaded.Lambdas != tagger.Lambdas {
+		t.Fatal("loaded model differs from saved one")
+	}
+	if err := (&PerceptronTagger{}).LoadModel(tagger.ModelPath); err == nil {
+		t.Fatal("perceptron tagger loaded hmm model")
+	}
+}
+
+func TestHMMTaggerWrongInput(t *testing.T) {
+	tagger, _ := NewHMMTagger(TaggerConfig{})
+	if err := tagger.Train(nil, 1, nil); err == nil {
+		t.Fatal("expected error for empty corpus")
+	}
+	if err := tagger.Train([]WordsTags{{Words: []string{}, Tags: []string{}}}, 1, nil); err == nil {
+		t.Fatal("expected error for corpus without words")
+	}
+	mismatch := []WordsTags{{Words: []string{"the", "dog"}, Tags: []string{"DT"}}}
+	if err := tagger.Train(mismatch, 1, nil); err == nil {
+		t.Fatal("expected error for sentence with missing tags")
+	}
+	// untrained model doesn't panic
+	if tags := tagger.TagWords([]string{"the", "dog"}); !reflect.DeepEqual(tags, []string{"", ""}) {
+		t.Fatalf("wrong tags of untrained model: %v", tags)
+	}
+	tagger.Tags = []string{BoundaryTag, "DT"}
+	tagger.update()
+	if tags := tagger.TagWords([]string{"the"}); !reflect.DeepEqual(tags, []string{""}) {
+		t.Fatalf("wrong tags of model without counts: %v", tags)
+	}
+}
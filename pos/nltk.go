@@ -0,0 +1,127 @@
+package pos
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/korobool/nlp4go/ml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Names of features extracted by getFeatures (and by NLTK's PerceptronTagger)
+// with count of space-separated values that follow the name
+var featureNames = map[string]int{
+	"bias":           0,
+	"i suffix":       1,
+	"i pref1":        1,
+	"i-1 tag":        1,
+	"i-2 tag":        1,
+	"i tag+i-2 tag":  2,
+	"i word":         1,
+	"i-1 tag+i word": 2,
+	"i-1 word":       1,
+	"i-1 suffix":     1,
+	"i-2 word":       1,
+	"i+1 word":       1,
+	"i+1 suffix":     1,
+	"i+2 word":       1,
+}
+
+// Checks that feature is one of extracted by getFeatures with
+// FeatureTemplateV2 (values may contain spaces only in words, so
+// only name and count of values are checked)
+func checkNLTKFeature(feature string) error {
+	for name, values := range featureNames {
+		if feature == name && values == 0 {
+			return nil
+		}
+		if !strings.HasPrefix(feature, name+" ") {
+			continue
+		}
+		value := feature[len(name)+1:]
+		if name == "i pref1" && len([]rune(value)) > 1 {
+			return fmt.Errorf("feature %q is not a single character prefix", feature)
+		}
+		if values == 2 && !strings.Contains(value, " ") {
+			return fmt.Errorf("feature %q should have two values", feature)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown feature %q", feature)
+}
+
+// ImportNLTK reads NLTK averaged perceptron tagger model from directory
+// in layout of nltk_data/taggers/averaged_perceptron_tagger_eng
+// (NLTK 3.8.2+): <name>.weights.json, <name>.tagdict.json and
+// <name>.classes.json
+func (t *PerceptronTagger) ImportNLTK(dir string) error {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.weights.json"))
+	if err != nil {
+		return err
+	}
+	if len(matches) != 1 {
+		return fmt.Errorf("expected single *.weights.json file in %s, found %d", dir, len(matches))
+	}
+	prefix := strings.TrimSuffix(matches[0], ".weights.json")
+
+	var files [3]*os.File
+	for i, suffix := range []string{".weights.json", ".tagdict.json", ".classes.json"} {
+		files[i], err = os.Open(prefix + suffix)
+		if err != nil {
+			return err
+		}
+		defer files[i].Close()
+	}
+	return t.ImportNLTKJSON(files[0], files[1], files[2])
+}
+
+// ImportNLTKJSON reads NLTK model from JSON encoded weights
+// (feature -> tag -> weight), tag dictionary (word -> tag) and list of tags.
+// Weights exported from pickled models of older NLTK versions
+// (json.dump of model.weights, tagdict and list(classes)) are read as well
+func (t *PerceptronTagger) ImportNLTKJSON(weightsReader, tagdictReader, classesReader io.Reader) error {
+	var weights map[string]map[string]float64
+	var tagdict map[string]string
+	var classes []string
+
+	if err := json.NewDecoder(weightsReader).Decode(&weights); err != nil {
+		return fmt.Errorf("can't decode NLTK weights: %w", err)
+	}
+	if err := json.NewDecoder(tagdictReader).Decode(&tagdict); err != nil {
+		return fmt.Errorf("can't decode NLTK tag dictionary: %w", err)
+	}
+	if err := json.NewDecoder(classesReader).Decode(&classes); err != nil {
+		return fmt.Errorf("can't decode NLTK classes: %w", err)
+	}
+
+	t.Classes = make(map[string]struct{}, len(classes))
+	for _, class := range classes {
+		t.Classes[class] = struct{}{}
+	}
+	for feature, classWeights := range weights {
+		if err := checkNLTKFeature(feature); err != nil {
+			return &ml.IncompatibleModelError{Field: "feature template", Expected: DefaultFeatureTemplate, Got: err.Error()}
+		}
+		for class := range classWeights {
+			if _, ok := t.Classes[class]; !ok {
+				return fmt.Errorf("weight of feature %q for unknown class %q", feature, class)
+			}
+		}
+	}
+	for word, tag := range tagdict {
+		if _, ok := t.Classes[tag]; !ok {
+			return fmt.Errorf("tag dictionary entry %q has unknown class %q", word, tag)
+		}
+	}
+
+	t.TagMap = tagdict
+	t.FeatureTemplate = FeatureTemplateV2
+	t.Training = ml.TrainingInfo{Params: map[string]string{"source": "nltk"}}
+	t.Model = ml.NewAveragedPerceptron()
+	t.Model.TieBreak = ml.TieBreakGreatest
+	t.addClasses()
+	t.Model.ImportWeights(weights)
+	return nil
+}
@@ -0,0 +1,80 @@
+package pos
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/korobool/nlp4go/ml"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testdata/nltk contains model in NLTK file format and tags of sample
+// sentences written by testdata/nltk/generate.py. Committed fixture is
+// generated with --port (Python port of nltk/tag/perceptron.py), so it
+// checks that tagging follows the port, compatibility with real NLTK is
+// checked after regenerating fixture by NLTK itself (see generate.py)
+func TestImportNLTK(t *testing.T) {
+	tagger, err := NewPerceptronTagger(TaggerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tagger.ImportNLTK("testdata/nltk"); err != nil {
+		t.Fatalf("can't import NLTK model: %v", err)
+	}
+	// frequent unambiguous words are tagged by tagdict, ambiguous ones by model
+	if len(tagger.TagMap) < 10 || tagger.TagMap["the"] != "DT" {
+		t.Fatalf("wrong tagdict: %v", tagger.TagMap)
+	}
+	if tag, ok := tagger.TagMap["fish"]; ok {
+		t.Fatalf("ambiguous word is in tagdict as %s", tag)
+	}
+
+	data, err := os.ReadFile("testdata/nltk/expected_tags.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected []WordsTags
+	if err := json.Unmarshal(data, &expected); err != nil {
+		t.Fatal(err)
+	}
+	check := func(tagger *PerceptronTagger) {
+		for _, sent := range expected {
+			if tags := tagger.TagWords(sent.Words); !reflect.DeepEqual(tags, sent.Tags) {
+				t.Fatalf("tags of %q differ from ones of reference implementation:\n%v\n%v", strings.Join(sent.Words, " "), tags, sent.Tags)
+			}
+		}
+	}
+	check(tagger)
+
+	// imported model is saved with NLTK compatible features
+	tagger.ModelPath = filepath.Join(t.TempDir(), "nltk.model")
+	if err := tagger.SaveModel(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewPerceptronTagger(TaggerConfig{LoadModel: true, ModelPath: tagger.ModelPath})
+	if err != nil {
+		t.Fatalf("can't load saved model: %v", err)
+	}
+	if loaded.FeatureTemplate != FeatureTemplateV2 {
+		t.Fatalf("wrong feature template: %s", loaded.FeatureTemplate)
+	}
+	check(loaded)
+}
+
+func TestImportNLTKIncompatibleFeatures(t *testing.T) {
+	tagger, _ := NewPerceptronTagger(TaggerConfig{})
+	for _, weights := range []string{
+		`{"i pref1 Hel": {"NN": 1}}`,
+		`{"i word+i+1 word the cat": {"NN": 1}}`,
+		`{"i tag+i-2 tag DT": {"NN": 1}}`,
+	} {
+		err := tagger.ImportNLTKJSON(strings.NewReader(weights), strings.NewReader(`{}`), strings.NewReader(`["NN"]`))
+		var incompatible *ml.IncompatibleModelError
+		if !errors.As(err, &incompatible) {
+			t.Fatalf("expected IncompatibleModelError for %s, got %v", weights, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package pos
+
+import (
+	"bufio"
+	"errors"
+	"github.com/korobool/nlp4go/ml"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Kind of PerceptronTagger training checkpoints in model file header
+const PerceptronCheckpointKind = "perceptron_tagger_checkpoint"
+
+// Payload of checkpoint file, model is saved with full training state
+type perceptronCheckpointDump struct {
+	Model              ml.PerceptronCheckpoint
+	TagMap             map[string]string
+	FrequencyThreshold int
+	AmbiguityThreshold float64
+	BeamWidth          int
+	// Count of completed epochs, Training.Rounds is count of planned ones
+	Epoch     int
+	Seed      int64
+	Workers   int
+	Templates *FeatureTemplates
+}
+
+// WriteCheckpoint writes tagger with full training state of model to `w`,
+// so training can be resumed by ResumeTraining or continued by FineTune
+func (t *PerceptronTagger) WriteCheckpoint(w io.Writer) error {
+	header := ml.ModelHeader{
+		Kind:            PerceptronCheckpointKind,
+		Tagset:          t.tagset(),
+		FeatureTemplate: t.FeatureTemplate,
+		Training:        t.Training,
+		Compression:     t.Compression,
+	}
+	dump := perceptronCheckpointDump{
+		Model:              ml.PerceptronCheckpoint{Model: t.Model},
+		TagMap:             t.TagMap,
+		FrequencyThreshold: t.FrequencyThreshold,
+		AmbiguityThreshold: t.AmbiguityThreshold,
+		BeamWidth:          t.BeamWidth,
+		Epoch:              t.epoch,
+		Seed:               t.seed,
+		Workers:            t.workers,
+		Templates:          t.customTemplates(),
+	}
+	return ml.WriteModel(w, header, &dump)
+}
+
+// SaveCheckpoint writes checkpoint to file, the file is replaced only
+// when checkpoint is written completely
+func (t *PerceptronTagger) SaveCheckpoint(filename string) error {
+	tmp := filename + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := t.WriteCheckpoint(file); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// ReadCheckpoint reads checkpoint written by WriteCheckpoint
+func (t *PerceptronTagger) ReadCheckpoint(r io.Reader) error {
+	var dump perceptronCheckpointDump
+	header, err := ml.ReadModel(r, &dump)
+	if err != nil {
+		return err
+	}
+	if header.Kind != PerceptronCheckpointKind {
+		return &ml.IncompatibleModelError{Field: "kind", Expected: PerceptronCheckpointKind, Got: header.Kind}
+	}
+	if err := checkFeatureTemplate(header.FeatureTemplate, dump.Templates); err != nil {
+		return err
+	}
+	if dump.Model.Model == nil || dump.TagMap == nil {
+		return &ml.CorruptedModelError{Reason: "incomplete perceptron tagger checkpoint"}
+	}
+
+	t.Model = dump.Model.Model
+	t.TagMap = dump.TagMap
+	t.FrequencyThreshold = dump.FrequencyThreshold
+	t.AmbiguityThreshold = dump.AmbiguityThreshold
+	t.BeamWidth = dump.BeamWidth
+	t.epoch, t.seed, t.workers = dump.Epoch, dump.Seed, dump.Workers
+	t.Compression = header.Compression
+	t.Training = header.Training
+	t.FeatureTemplate = header.FeatureTemplate
+	t.Templates = dump.Templates
+	t.Classes = make(map[string]struct{}, len(header.Tagset))
+	for _, class := range header.Tagset {
+		t.Classes[class] = struct{}{}
+	}
+	return nil
+}
+
+// LoadCheckpoint reads checkpoint saved by SaveCheckpoint
+func (t *PerceptronTagger) LoadCheckpoint(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return t.ReadCheckpoint(bufio.NewReader(file))
+}
+
+// ResumeTraining continues interrupted training of tagger loaded by
+// LoadCheckpoint on the same sentences (in the same order) until all
+// planned epochs are completed, then weights are averaged.
+// Checkpoints don't keep the best model and accuracy on dev set, so
+// resumed training has no early stopping and keeps model of the last epoch
+func (t *PerceptronTagger) ResumeTraining(sentences []WordsTags, progressFn Callback) error {
+	if t.Model.Updates() == 0 {
+		return errors.New("model has no training state, use FineTune to continue training")
+	}
+	_, err := t.trainRounds(sentences, TrainOptions{Rounds: t.Training.Rounds, Progress: progressFn})
+	return err
+}
+
+// FineTune continues training of loaded model (saved by SaveModel or
+// SaveCheckpoint) on new sentences for `rounds` epochs. Tag dictionary
+// is extended by words of new sentences. Weights of averaged models are
+// kept as a starting point, training state of checkpoints is continued
+func (t *PerceptronTagger) FineTune(sentences []WordsTags, rounds int, progressFn Callback) error {
+
+	t.makeTagMap(&sentences)
+	t.addClasses()
+
+	if t.Training.Params == nil {
+		t.Training.Params = make(map[string]string)
+	}
+	t.Training.Params["fine_tune_rounds"] = strconv.Itoa(rounds)
+	t.Training.Params["fine_tune_sentences"] = strconv.Itoa(len(sentences))
+	// epochs are counted from the start of training of base model
+	t.epoch = t.Training.Rounds
+	t.Training.Rounds += rounds
+	t.Training.Sentences += len(sentences)
+	for _, sent := range sentences {
+		t.Training.Tokens += len(sent.Words)
+	}
+	_, err := t.trainRounds(sentences, TrainOptions{Rounds: t.Training.Rounds, Progress: progressFn})
+	return err
+}
@@ -0,0 +1,72 @@
+package pos
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResumeTraining(t *testing.T) {
+	full, _ := NewPerceptronTagger(TaggerConfig{})
+	full.Train(beamSentences, 4, nil)
+
+	path := filepath.Join(t.TempDir(), "checkpoint.bin")
+	tagger, _ := NewPerceptronTagger(TaggerConfig{})
+	tagger.CheckpointPath = path
+	if err := tagger.Train(beamSentences, 2, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := tagger.ResumeTraining(beamSentences, nil); err == nil {
+		t.Fatal("expected error for averaged model")
+	}
+
+	resumed, _ := NewPerceptronTagger(TaggerConfig{})
+	if err := resumed.LoadCheckpoint(path); err != nil {
+		t.Fatalf("can't load checkpoint: %v", err)
+	}
+	if resumed.epoch != 2 || resumed.Training.Rounds != 2 || len(resumed.Classes) != len(full.Classes) {
+		t.Fatalf("wrong state of checkpoint: epoch %d of %d", resumed.epoch, resumed.Training.Rounds)
+	}
+	// as if training of 4 epochs was interrupted after 2 of them
+	resumed.Training.Rounds = 4
+	if err := resumed.ResumeTraining(beamSentences, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resumed.Model.ExportWeights(), full.Model.ExportWeights()) {
+		t.Fatal("resumed model differs from uninterrupted one")
+	}
+
+	if err := resumed.LoadModel(path); err == nil {
+		t.Fatal("expected error for loading checkpoint as model")
+	}
+}
+
+func TestFineTune(t *testing.T) {
+	base, _ := NewPerceptronTagger(TaggerConfig{ModelPath: filepath.Join(t.TempDir(), "model.bin")})
+	base.Train(append([]WordsTags(nil), beamSentences...), 5, nil)
+	if err := base.SaveModel(); err != nil {
+		t.Fatal(err)
+	}
+
+	tagger, err := NewPerceptronTagger(TaggerConfig{ModelPath: base.ModelPath, LoadModel: true})
+	if err != nil {
+		t.Fatalf("can't load model: %v", err)
+	}
+	domain := makeSentences(
+		"the/DT kernel/NN panics/VBZ ./.",
+		"a/DT driver/NN crashes/VBZ ./.",
+		"the/DT kernel/NN loads/VBZ a/DT driver/NN ./.",
+	)
+	if err := tagger.FineTune(append([]WordsTags(nil), domain...), 5, nil); err != nil {
+		t.Fatal(err)
+	}
+	if tagger.Training.Rounds != 10 || tagger.Training.Sentences != len(beamSentences)+len(domain) ||
+		tagger.Training.Params["fine_tune_rounds"] != "5" {
+		t.Fatalf("wrong training info: %+v", tagger.Training)
+	}
+	for _, sent := range domain {
+		if tags := tagger.TagWords(sent.Words); !reflect.DeepEqual(tags, sent.Tags) {
+			t.Fatalf("wrong tags of %v: %v", sent.Words, tags)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package pos
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"github.com/korobool/nlp4go/ml"
+	"io"
+	"os"
+	"sort"
+)
+
+const (
+	// Kind of PerceptronTagger models in model file header
+	PerceptronTaggerKind = "perceptron_tagger"
+	// IDs of features extracted by getFeatures, new ID must be added
+	// whenever features are changed, so incompatible models are rejected.
+	// In "default-v1" (models saved by previous versions) "i pref1" feature
+	// is a word without the last rune, "default-v2" uses the first rune
+	// as NLTK does
+	FeatureTemplateV1      = "default-v1"
+	FeatureTemplateV2      = "default-v2"
+	DefaultFeatureTemplate = FeatureTemplateV2
+)
+
+// Payload of PerceptronTagger model file
+type perceptronTaggerDump struct {
+	Model              *ml.AveragedPerceptron
+	TagMap             map[string]string
+	FrequencyThreshold int
+	AmbiguityThreshold float64
+	// Features of FeatureTemplateCustom
+	Templates *FeatureTemplates
+}
+
+// Returns sorted tagset
+func (t *PerceptronTagger) tagset() []string {
+	classes := make([]string, 0, len(t.Classes))
+	for class := range t.Classes {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+// LoadModel reads model saved by SaveModel, models saved in legacy
+// format (gob encoded map) are loaded as well
+func (t *PerceptronTagger) LoadModel(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	if !ml.IsModelFile(r) {
+		return t.readLegacyModel(r)
+	}
+	return t.ReadModel(r)
+}
+
+// ReadModel reads model written by WriteModel
+func (t *PerceptronTagger) ReadModel(r io.Reader) error {
+	var dump perceptronTaggerDump
+	header, err := ml.ReadModel(r, &dump)
+	if err != nil {
+		return err
+	}
+	if header.Kind != PerceptronTaggerKind {
+		return &ml.IncompatibleModelError{Field: "kind", Expected: PerceptronTaggerKind, Got: header.Kind}
+	}
+	if err := checkFeatureTemplate(header.FeatureTemplate, dump.Templates); err != nil {
+		return err
+	}
+	if dump.Model == nil || dump.TagMap == nil {
+		return &ml.CorruptedModelError{Reason: "incomplete perceptron tagger model"}
+	}
+
+	t.Model = dump.Model
+	t.TagMap = dump.TagMap
+	t.FrequencyThreshold = dump.FrequencyThreshold
+	t.AmbiguityThreshold = dump.AmbiguityThreshold
+	t.Compression = header.Compression
+	t.Training = header.Training
+	t.FeatureTemplate = header.FeatureTemplate
+	t.Templates = dump.Templates
+	t.Classes = make(map[string]struct{}, len(header.Tagset))
+	for _, class := range header.Tagset {
+		t.Classes[class] = struct{}{}
+	}
+	return nil
+}
+
+// Reads model saved as gob encoded map by previous versions
+func (t *PerceptronTagger) readLegacyModel(r io.Reader) error {
+	gob.Register(map[string]map[string]float64{})
+	gob.Register(map[string]string{})
+	gob.Register(map[string]struct{}{})
+
+	dump := make(map[string]interface{})
+	if err := gob.NewDecoder(r).Decode(&dump); err != nil {
+		return &ml.CorruptedModelError{Reason: "can't decode legacy model: " + err.Error()}
+	}
+
+	weights, ok := dump["weights"].(map[string]map[string]float64)
+	if !ok {
+		return &ml.CorruptedModelError{Reason: "legacy model has no weights"}
+	}
+	tagMap, ok := dump["tagmap"].(map[string]string)
+	if !ok {
+		return &ml.CorruptedModelError{Reason: "legacy model has no tag map"}
+	}
+	classes, ok := dump["classes"].(map[string]struct{})
+	if !ok {
+		return &ml.CorruptedModelError{Reason: "legacy model has no classes"}
+	}
+
+	t.TagMap = tagMap
+	t.Classes = classes
+	t.Training = ml.TrainingInfo{}
+	t.FeatureTemplate = FeatureTemplateV1
+	t.Templates = nil
+	t.Model = ml.NewAveragedPerceptron()
+	t.addClasses()
+	t.Model.ImportWeights(weights)
+	return nil
+}
+
+// WriteModel writes model to `w` in versioned model file format
+func (t *PerceptronTagger) WriteModel(w io.Writer) error {
+	header := ml.ModelHeader{
+		Kind:            PerceptronTaggerKind,
+		Tagset:          t.tagset(),
+		FeatureTemplate: t.FeatureTemplate,
+		Training:        t.Training,
+		Compression:     t.Compression,
+	}
+	dump := perceptronTaggerDump{
+		Model:              t.Model,
+		TagMap:             t.TagMap,
+		FrequencyThreshold: t.FrequencyThreshold,
+		AmbiguityThreshold: t.AmbiguityThreshold,
+		Templates:          t.customTemplates(),
+	}
+	return ml.WriteModel(w, header, &dump)
+}
+
+// SaveModel writes model to ModelPath
+func (t *PerceptronTagger) SaveModel() error {
+	file, err := os.Create(t.ModelPath)
+	if err != nil {
+		return err
+	}
+	if err := t.WriteModel(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// MigrateModel converts model saved by previous versions of PerceptronTagger
+// (or model in current format, e.g. to change compression) to current format
+func MigrateModel(src, dst string, compression ml.Compression) error {
+	tagger := &PerceptronTagger{ModelPath: dst}
+	if err := tagger.LoadModel(src); err != nil {
+		return fmt.Errorf("can't load model %s: %w", src, err)
+	}
+	tagger.Compression = compression
+	return tagger.SaveModel()
+}
@@ -0,0 +1,72 @@
+package pos
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"github.com/korobool/nlp4go/ml"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLegacyModelMigration(t *testing.T) {
+	dir := t.TempDir()
+	legacyPath := filepath.Join(dir, "legacy.gob")
+
+	gob.Register(map[string]map[string]float64{})
+	gob.Register(map[string]string{})
+	gob.Register(map[string]struct{}{})
+	legacy := map[string]interface{}{
+		"weights": map[string]map[string]float64{
+			"i suffix ing": {"VBG": 2.5, "NN": -0.5},
+			"i-1 tag DT":   {"NN": 1.2},
+		},
+		"tagmap":  map[string]string{"the": "DT"},
+		"classes": map[string]struct{}{"DT": {}, "NN": {}, "VBG": {}},
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(legacy); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacyPath, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	newPath := filepath.Join(dir, "model.bin")
+	if err := MigrateModel(legacyPath, newPath, ml.CompressionGzip); err != nil {
+		t.Fatalf("can't migrate model: %v", err)
+	}
+
+	tagger := &PerceptronTagger{}
+	if err := tagger.LoadModel(newPath); err != nil {
+		t.Fatalf("can't load migrated model: %v", err)
+	}
+	if tagger.Compression != ml.CompressionGzip || tagger.TagMap["the"] != "DT" || len(tagger.Classes) != 3 {
+		t.Fatalf("wrong migrated model: %+v", tagger)
+	}
+	weights := tagger.Model.ExportWeights()
+	if weights["i suffix ing"]["VBG"] != 2.5 || weights["i-1 tag DT"]["NN"] != 1.2 {
+		t.Fatalf("wrong migrated weights: %v", weights)
+	}
+	if tag := tagger.Model.PredictFeatures([]string{"i suffix ing"}); tag != "VBG" {
+		t.Fatalf("wrong prediction of migrated model: %s", tag)
+	}
+}
+
+func TestLoadBrokenLegacyModel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.gob")
+	var buf bytes.Buffer
+	gob.Register(map[string]string{})
+	gob.NewEncoder(&buf).Encode(map[string]interface{}{"tagmap": map[string]string{}})
+
+	var corrupted *ml.CorruptedModelError
+	for _, data := range [][]byte{buf.Bytes(), []byte("garbage")} {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := (&PerceptronTagger{}).LoadModel(path); !errors.As(err, &corrupted) {
+			t.Fatalf("expected CorruptedModelError, got %v", err)
+		}
+	}
+}
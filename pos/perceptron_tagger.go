@@ -1,11 +1,9 @@
 package pos
 
 import (
-	"encoding/gob"
 	"github.com/korobool/nlp4go/ml"
 	"github.com/korobool/nlp4go/tokenize"
-	"math/rand"
-	"os"
+	"sort"
 	"strings"
 	"unicode"
 )
@@ -23,6 +21,25 @@ type PerceptronTagger struct {
 	Model              *ml.AveragedPerceptron
 	TagMap             map[string]string
 	Classes            map[string]struct{}
+	// ID of features used by model, see DefaultFeatureTemplate
+	FeatureTemplate string
+	// Features of FeatureTemplateCustom
+	Templates *FeatureTemplates
+	// Compression of saved model
+	Compression ml.Compression
+	// Filled by Train and LoadModel
+	Training ml.TrainingInfo
+	// If it's greater than 1, sentences are tagged by beam search and
+	// trained by structured perceptron with early update
+	BeamWidth int
+	// If it's set, Train writes checkpoint there after every epoch
+	CheckpointPath string
+	// Count of completed epochs of training
+	epoch int
+	// Seed of shuffling of sentences and count of parallel workers,
+	// see TrainOptions
+	seed    int64
+	workers int
 }
 
 func NewPerceptronTagger(config TaggerConfig) (*PerceptronTagger, error) {
@@ -34,14 +51,21 @@ func NewPerceptronTagger(config TaggerConfig) (*PerceptronTagger, error) {
 		START_TOK:          []string{"-START-", "-START2-"},
 		END_TOK:            []string{"-END-", "-END2-"},
 		ModelPath:          "avp_tagger_model.gob",
+		FeatureTemplate:    DefaultFeatureTemplate,
 		Model:              ml.NewAveragedPerceptron(),
 		TagMap:             make(map[string]string),
 		Classes:            make(map[string]struct{}),
+		BeamWidth:          config.BeamWidth,
 	}
 
 	if config.ModelPath != "" {
 		tagger.ModelPath = config.ModelPath
 	}
+	if config.Templates != nil {
+		if err := tagger.SetTemplates(config.Templates); err != nil {
+			return nil, err
+		}
+	}
 	if config.LoadModel {
 		err := tagger.LoadModel(tagger.ModelPath)
 		if err != nil {
@@ -51,74 +75,113 @@ func NewPerceptronTagger(config TaggerConfig) (*PerceptronTagger, error) {
 	return &tagger, nil
 }
 
+// Extension attributes of tokens set by TagTopK
+var (
+	// Pseudo-probability of assigned tag, 1 for tags taken from tag dictionary
+	PosConfidence = tokenize.MustRegisterAttribute("pos_confidence", 1.0)
+	// Difference between scores of assigned tag and the next best one
+	PosMargin = tokenize.MustRegisterAttribute("pos_margin", 0.0)
+	// The best tags (assigned tag first) and their pseudo-probabilities
+	PosAlternatives     = tokenize.MustRegisterAttribute[[]string]("pos_alternatives", nil)
+	PosAlternativeProbs = tokenize.MustRegisterAttribute[[]float64]("pos_alternative_probs", nil)
+)
+
 func (t *PerceptronTagger) Tag(sentence string) ([]*tokenize.Token, error) {
 
 	tokens := t.tokenizer.Tokenize(sentence)
+	t.tagTokens(tokens, 0)
+	return tokens, nil
+}
 
-	prev, prev2 := t.START_TOK[0], t.START_TOK[1]
-
-	context := make([]string, 0, len(tokens)+4)
-	context = append(context, t.START_TOK...)
+// TagWords tags already tokenized sentence
+func (t *PerceptronTagger) TagWords(words []string) []string {
 
-	for _, token := range tokens {
-		context = append(context, t.normalize(token.Word, token.Runes))
+	tokens := make([]*tokenize.Token, len(words))
+	for i, word := range words {
+		runes := []rune(word)
+		tokens[i] = tokenize.NewToken(runes, 0, len(runes))
 	}
-	context = append(context, t.END_TOK...)
+	t.tagTokens(tokens, 0)
 
+	tags := make([]string, len(tokens))
 	for i, token := range tokens {
-		tag, ok := t.TagMap[token.Word]
-		if !ok {
-			features := t.getFeatures(i, token.Word, context, prev, prev2)
-			tag = t.Model.Predict(features)
-		}
-		tokens[i].PosTag = tag
-		prev2 = prev
-		prev = tag
+		tags[i] = token.PosTag
 	}
-	return tokens, nil
+	return tags
 }
 
-func (t *PerceptronTagger) Train(sentences []WordsTags, rounds int, progressFn Callback) {
+// TagTopK tags sentence greedily (regardless of BeamWidth) and sets extension attributes of tokens:
+// PosConfidence, PosMargin and up to `k` alternatives with their
+// probabilities (PosAlternatives, PosAlternativeProbs)
+func (t *PerceptronTagger) TagTopK(sentence string, k int) ([]*tokenize.Token, error) {
 
-	t.makeTagMap(&sentences)
-
-	prev, prev2 := t.START_TOK[0], t.START_TOK[1]
+	tokens := t.tokenizer.Tokenize(sentence)
+	if k < 1 {
+		k = 1
+	}
+	t.tagTokens(tokens, k)
+	return tokens, nil
+}
 
-	for it := 0; it < rounds; it += 1 {
-		cnt := 1
-		for _, wordsTags := range sentences {
+// Sets tags of tokens, sets attributes of top-k prediction if `k` is positive
+// (top-k tagging is always greedy)
+func (t *PerceptronTagger) tagTokens(tokens []*tokenize.Token, k int) {
 
-			context := make([]string, 0, len(wordsTags.Words)+4)
-			context = append(context, t.START_TOK...)
+	if t.BeamWidth > 1 && k == 0 {
+		words := make([]string, len(tokens))
+		for i, token := range tokens {
+			words[i] = token.Word
+		}
+		for i, tag := range t.beamTags(words) {
+			tokens[i].PosTag = tag
+		}
+		return
+	}
 
-			for _, word := range wordsTags.Words {
-				context = append(context, t.normalize(word, []rune(word)))
-			}
-			context = append(context, t.END_TOK...)
+	prev, prev2 := t.START_TOK[0], t.START_TOK[1]
 
-			for i, word := range wordsTags.Words {
-				guess, ok := t.TagMap[word]
-				if !ok {
-					features := t.getFeatures(i, word, context, prev, prev2)
-					guess = t.Model.Predict(features)
+	words := make([]string, len(tokens))
+	for i, token := range tokens {
+		words[i] = token.Word
+	}
+	context := t.makeContext(words)
 
-					t.Model.Update(wordsTags.Tags[i], guess, features)
-				}
-				prev2 = prev
-				prev = guess
-			}
-			if progressFn != nil {
-				progressFn((it+1)*cnt, len(sentences)*rounds)
-			}
-			cnt += 1
-		}
-		if (it + 1) < rounds {
-			for i, v := range rand.Perm(len(sentences)) {
-				sentences[i], sentences[v] = sentences[v], sentences[i]
+	for i, token := range tokens {
+		tag, ok := t.TagMap[token.Word]
+		switch {
+		case ok && k > 0:
+			PosConfidence.Set(token, 1.0)
+			PosMargin.Del(token)
+			PosAlternatives.Set(token, []string{tag})
+			PosAlternativeProbs.Set(token, []float64{1.0})
+		case !ok && k > 0:
+			features := t.getFeatures(i, context, prev, prev2)
+			prediction := t.Model.PredictTopK(features, k)
+			tag = prediction.Best()
+
+			probs := make([]float64, len(prediction.Classes))
+			for j, class := range prediction.Classes {
+				probs[j] = class.Prob
 			}
+			PosConfidence.Set(token, prediction.Confidence())
+			PosMargin.Set(token, prediction.Margin)
+			PosAlternatives.Set(token, prediction.Labels())
+			PosAlternativeProbs.Set(token, probs)
+		case !ok:
+			features := t.getFeatures(i, context, prev, prev2)
+			tag = t.Model.PredictFeatures(features)
 		}
+		token.PosTag = tag
+		prev2 = prev
+		prev = tag
 	}
-	t.Model.AverageWeights()
+}
+
+// Train trains model on sentences for `rounds` epochs and averages weights,
+// see TrainWithOptions
+func (t *PerceptronTagger) Train(sentences []WordsTags, rounds int, progressFn Callback) error {
+	_, err := t.TrainWithOptions(sentences, TrainOptions{Rounds: rounds, Progress: progressFn})
+	return err
 }
 
 func (t *PerceptronTagger) makeTagMap(sentences *[]WordsTags) {
@@ -158,6 +221,19 @@ func (t *PerceptronTagger) makeTagMap(sentences *[]WordsTags) {
 	}
 }
 
+// Adds tagset to the model in sorted order, so class IDs don't depend
+// on order of sentences
+func (t *PerceptronTagger) addClasses() {
+	classes := make([]string, 0, len(t.Classes))
+	for class := range t.Classes {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		t.Model.ClassID(class, true)
+	}
+}
+
 func (t *PerceptronTagger) normalize(word string, runes []rune) string {
 
 	switch {
@@ -172,22 +248,51 @@ func (t *PerceptronTagger) normalize(word string, runes []rune) string {
 	}
 }
 
-func (t *PerceptronTagger) getFeatures(i int, word string, context []string, prev string, prev2 string) map[string]int {
+// SetTemplates sets features described by templates (FeatureTemplateCustom)
+// for training, loaded model uses features it was trained with
+func (t *PerceptronTagger) SetTemplates(templates *FeatureTemplates) error {
+	if err := templates.Validate(); err != nil {
+		return err
+	}
+	t.FeatureTemplate = FeatureTemplateCustom
+	t.Templates = templates
+	return nil
+}
+
+// Returns templates saved with model, nil for built-in features
+func (t *PerceptronTagger) customTemplates() *FeatureTemplates {
+	if t.FeatureTemplate != FeatureTemplateCustom {
+		return nil
+	}
+	return t.Templates
+}
 
-	features := map[string]int{}
+// Returns features of word `i` of sentence
+func (t *PerceptronTagger) getFeatures(i int, sentence sentenceContext, prev string, prev2 string) []string {
+
+	if t.FeatureTemplate == FeatureTemplateCustom {
+		return t.Templates.features(i, sentence, prev, prev2)
+	}
+
+	features := make([]string, 0, 14)
 
 	add := func(name string, slice ...string) {
 		str := make([]string, 0, len(slice)+1)
 		str = append(str, name)
 		str = append(str, slice...)
-		features[strings.Join(str, " ")] += 1
+		features = append(features, strings.Join(str, " "))
 	}
 
 	i += len(t.START_TOK)
+	word, context := sentence.words[i], sentence.normalized
 
 	add("bias")
 	add("i suffix", StrSuffix(word, 3))
-	add("i pref1", StrPrefix(word, 1))
+	if t.FeatureTemplate == FeatureTemplateV1 {
+		add("i pref1", StrPrefix(word, 1))
+	} else {
+		add("i pref1", string([]rune(word)[:1]))
+	}
 	add("i-1 tag", prev)
 	add("i-2 tag", prev2)
 	add("i tag+i-2 tag", prev, prev2)
@@ -203,50 +308,38 @@ func (t *PerceptronTagger) getFeatures(i int, word string, context []string, pre
 	return features
 }
 
-func (t *PerceptronTagger) LoadModel(filename string) error {
-	gob.Register(map[string]map[string]float64{})
-	gob.Register(map[string]string{})
-	gob.Register(map[string]struct{}{})
+// Features of getFeatures that depend on tags of previous words
+var tagFeatures = []string{"i-1 tag ", "i-2 tag ", "i tag+i-2 tag ", "i-1 tag+i word "}
 
-	dump := make(map[string]interface{})
+// ObservationFeatures returns features of getFeatures for every word of
+// sentence except ones that depend on previous tags, so sequence models
+// that score tag transitions themselves (ml.CRF) use the same templates
+func (t *PerceptronTagger) ObservationFeatures(words []string) ml.Sequence {
 
-	gobFile, err := os.Open(filename)
-	defer gobFile.Close()
-	if err != nil {
-		return err
+	context := t.makeContext(words)
+	var observations *FeatureTemplates
+	if t.FeatureTemplate == FeatureTemplateCustom {
+		observations = t.Templates.observations()
 	}
-	dec := gob.NewDecoder(gobFile)
-	err = dec.Decode(&dump)
-
-	t.Model.Weights = dump["weights"].(map[string]map[string]float64)
-	t.TagMap = dump["tagmap"].(map[string]string)
-	t.Classes = dump["classes"].(map[string]struct{})
 
-	return nil
-}
-
-func (t *PerceptronTagger) SaveModel() error {
-
-	gob.Register(map[string]map[string]float64{})
-	gob.Register(map[string]string{})
-	gob.Register(map[string]struct{}{})
-
-	data := make(map[string]interface{})
-
-	data["weights"] = t.Model.Weights
-	data["tagmap"] = t.TagMap
-	data["classes"] = t.Classes
-
-	gobFile, err := os.Create(t.ModelPath)
-	defer gobFile.Close()
-	if err != nil {
-		return err
-	}
-
-	enc := gob.NewEncoder(gobFile)
-	if err := enc.Encode(data); err != nil {
-		return err
+	sequence := make(ml.Sequence, len(words))
+	for i := range words {
+		if observations != nil {
+			sequence[i] = observations.features(i, context, "", "")
+			continue
+		}
+		features := t.getFeatures(i, context, "", "")
+		observed := features[:0]
+	next:
+		for _, feature := range features {
+			for _, prefix := range tagFeatures {
+				if strings.HasPrefix(feature, prefix) {
+					continue next
+				}
+			}
+			observed = append(observed, feature)
+		}
+		sequence[i] = observed
 	}
-
-	return nil
+	return sequence
 }
@@ -0,0 +1,251 @@
+package pos
+
+import (
+	"errors"
+	"github.com/korobool/nlp4go/ml"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Options of PerceptronTagger training
+type TrainOptions struct {
+	// Count of epochs
+	Rounds int
+	// Seed of shuffling of sentences before every epoch (except the
+	// first one), training with the same seed is reproducible
+	Seed int64
+	// If it's set, accuracy of averaged model on Dev is measured after
+	// every epoch and model of the most accurate epoch is kept
+	Dev []WordsTags
+	// Training stops if accuracy on Dev hasn't improved for Patience
+	// epochs, 0 disables early stopping
+	Patience int
+	// If it's greater than 1, sentences of every epoch are split into
+	// Workers shards trained in parallel by forks of model, which are
+	// mixed after every epoch (iterative parameter mixing)
+	Workers  int
+	Progress Callback
+}
+
+// Statistics of training epoch
+type EpochStats struct {
+	// Number of epoch counted from 1
+	Epoch int
+	// Count of wrong tags predicted during training (before updates),
+	// beam training counts them only up to the word of early update
+	Errors int
+	Tokens int
+	// Accuracy on training sentences
+	Accuracy float64
+	// Accuracy on TrainOptions.Dev, 0 without it
+	DevAccuracy float64
+	Elapsed     time.Duration
+}
+
+// Statistics of training returned by TrainWithOptions
+type TrainStats struct {
+	Epochs []EpochStats
+	// Number of epoch of kept model
+	BestEpoch int
+	// Reports whether training was stopped early
+	Stopped bool
+}
+
+// TrainWithOptions trains model on sentences and averages weights.
+// If CheckpointPath is set, checkpoint is written after every epoch,
+// errors are returned only by writing of checkpoints. Sentences aren't modified
+func (t *PerceptronTagger) TrainWithOptions(sentences []WordsTags, opts TrainOptions) (*TrainStats, error) {
+
+	if t.FeatureTemplate == FeatureTemplateCustom && t.Templates == nil {
+		return nil, errors.New("custom features have no templates, see SetTemplates")
+	}
+	if err := checkFeatureTemplate(t.FeatureTemplate, t.Templates); err != nil {
+		return nil, err
+	}
+	t.makeTagMap(&sentences)
+	t.addClasses()
+
+	t.Training = ml.TrainingInfo{
+		Created:   time.Now().UTC(),
+		Rounds:    opts.Rounds,
+		Sentences: len(sentences),
+		Params: map[string]string{
+			"frequency_threshold": strconv.Itoa(t.FrequencyThreshold),
+			"ambiguity_threshold": strconv.FormatFloat(t.AmbiguityThreshold, 'g', -1, 64),
+			"seed":                strconv.FormatInt(opts.Seed, 10),
+		},
+	}
+	if t.BeamWidth > 1 {
+		t.Training.Params["beam_width"] = strconv.Itoa(t.BeamWidth)
+	}
+	if opts.Workers > 1 {
+		t.Training.Params["workers"] = strconv.Itoa(opts.Workers)
+	}
+	for _, sent := range sentences {
+		t.Training.Tokens += len(sent.Words)
+	}
+	t.epoch, t.seed, t.workers = 0, opts.Seed, opts.Workers
+
+	stats, err := t.trainRounds(sentences, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Dev != nil {
+		t.Training.Params["patience"] = strconv.Itoa(opts.Patience)
+		t.Training.Params["best_epoch"] = strconv.Itoa(stats.BestEpoch)
+		// kept model is trained for BestEpoch epochs
+		t.Training.Rounds = stats.BestEpoch
+	}
+	return stats, nil
+}
+
+// Trains model until `opts.Rounds` epochs are completed (or until early
+// stopping) and averages weights
+func (t *PerceptronTagger) trainRounds(sentences []WordsTags, opts TrainOptions) (*TrainStats, error) {
+
+	stats := &TrainStats{}
+	var best *ml.AveragedPerceptron
+	var bestAccuracy float64
+	var stale int
+	for it := t.epoch; it < opts.Rounds; it += 1 {
+		start := time.Now()
+		epoch := EpochStats{Epoch: it + 1}
+
+		order := t.epochOrder(len(sentences), it)
+		progress := func(cnt int) {
+			if opts.Progress != nil {
+				opts.Progress(it*len(sentences)+cnt, len(sentences)*opts.Rounds)
+			}
+		}
+		if t.workers > 1 {
+			epoch.Errors = t.trainParallel(sentences, order, t.workers, progress)
+		} else {
+			for cnt, idx := range order {
+				epoch.Errors += t.trainSentence(sentences[idx])
+				progress(cnt + 1)
+			}
+		}
+		for _, sent := range sentences {
+			epoch.Tokens += len(sent.Words)
+		}
+		if epoch.Tokens > 0 {
+			epoch.Accuracy = 1 - float64(epoch.Errors)/float64(epoch.Tokens)
+		}
+		t.epoch = it + 1
+
+		if opts.Dev != nil {
+			model := t.Model
+			t.Model = model.Averaged()
+			epoch.DevAccuracy = taggingAccuracy(t, opts.Dev)
+			if best == nil || epoch.DevAccuracy > bestAccuracy {
+				best, bestAccuracy, stats.BestEpoch, stale = t.Model, epoch.DevAccuracy, it+1, 0
+			} else {
+				stale++
+			}
+			t.Model = model
+		}
+		epoch.Elapsed = time.Since(start)
+		stats.Epochs = append(stats.Epochs, epoch)
+
+		if t.CheckpointPath != "" {
+			if err := t.SaveCheckpoint(t.CheckpointPath); err != nil {
+				return nil, err
+			}
+		}
+		if opts.Patience > 0 && stale >= opts.Patience {
+			stats.Stopped = true
+			break
+		}
+	}
+	if best != nil {
+		t.Model = best
+	} else {
+		t.Model.AverageWeights()
+		stats.BestEpoch = t.epoch
+	}
+	return stats, nil
+}
+
+// Returns order of sentences in epoch `it`, it depends only on seed and
+// epoch, so resumed training visits sentences in the same order
+func (t *PerceptronTagger) epochOrder(count, it int) []int {
+	if it == 0 {
+		order := make([]int, count)
+		for i := range order {
+			order[i] = i
+		}
+		return order
+	}
+	return rand.New(rand.NewSource(t.seed + int64(it))).Perm(count)
+}
+
+// Trains shards of sentences in parallel by forks of model and mixes them,
+// returns count of wrong tags
+func (t *PerceptronTagger) trainParallel(sentences []WordsTags, order []int, workers int, progress func(int)) int {
+
+	forks := make([]*ml.AveragedPerceptron, workers)
+	errors := make([]int, workers)
+	var mutex sync.Mutex
+	var done int
+	var wg sync.WaitGroup
+	for w := range forks {
+		forks[w] = t.Model.Fork()
+		// tag dictionary and settings are only read by training
+		local := *t
+		local.Model = forks[w]
+		wg.Add(1)
+		go func(w int, local *PerceptronTagger) {
+			defer wg.Done()
+			for i := w; i < len(order); i += workers {
+				errors[w] += local.trainSentence(sentences[order[i]])
+
+				mutex.Lock()
+				done++
+				progress(done)
+				mutex.Unlock()
+			}
+		}(w, &local)
+	}
+	wg.Wait()
+	t.Model.Mix(forks)
+
+	total := 0
+	for _, count := range errors {
+		total += count
+	}
+	return total
+}
+
+// Trains model on sentence, returns count of wrong tags
+func (t *PerceptronTagger) trainSentence(sentence WordsTags) int {
+	if t.BeamWidth > 1 {
+		return t.trainBeam(sentence)
+	}
+	return t.trainGreedy(sentence)
+}
+
+// Trains model on sentence by greedy tagging, returns count of wrong tags
+func (t *PerceptronTagger) trainGreedy(sentence WordsTags) int {
+
+	prev, prev2 := t.START_TOK[0], t.START_TOK[1]
+	context := t.makeContext(sentence.Words)
+
+	errors := 0
+	for i, word := range sentence.Words {
+		guess, ok := t.TagMap[word]
+		if !ok {
+			features := t.getFeatures(i, context, prev, prev2)
+			guess = t.Model.PredictFeatures(features)
+
+			t.Model.UpdateFeatures(sentence.Tags[i], guess, features)
+		}
+		if guess != sentence.Tags[i] {
+			errors++
+		}
+		prev2 = prev
+		prev = guess
+	}
+	return errors
+}
@@ -0,0 +1,149 @@
+package pos
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTrainWithOptions(t *testing.T) {
+	train := func(seed int64) (*PerceptronTagger, *TrainStats) {
+		tagger, _ := NewPerceptronTagger(TaggerConfig{})
+		stats, err := tagger.TrainWithOptions(beamSentences, TrainOptions{Rounds: 5, Seed: seed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tagger, stats
+	}
+	first, stats := train(7)
+	second, _ := train(7)
+	if !reflect.DeepEqual(first.Model.ExportWeights(), second.Model.ExportWeights()) {
+		t.Fatal("training with the same seed isn't reproducible")
+	}
+	if first.Training.Params["seed"] != "7" {
+		t.Fatalf("seed isn't saved in training info: %v", first.Training.Params)
+	}
+
+	if len(stats.Epochs) != 5 || stats.BestEpoch != 5 || stats.Stopped {
+		t.Fatalf("wrong training stats: %+v", stats)
+	}
+	for i, epoch := range stats.Epochs {
+		if epoch.Epoch != i+1 || epoch.Tokens != first.Training.Tokens || epoch.DevAccuracy != 0 ||
+			epoch.Accuracy != 1-float64(epoch.Errors)/float64(epoch.Tokens) {
+			t.Fatalf("wrong stats of epoch: %+v", epoch)
+		}
+	}
+	if stats.Epochs[4].Errors >= stats.Epochs[0].Errors {
+		t.Fatalf("training errors don't decrease: %+v", stats.Epochs)
+	}
+}
+
+func TestEarlyStopping(t *testing.T) {
+	tagger, _ := NewPerceptronTagger(TaggerConfig{})
+	stats, err := tagger.TrainWithOptions(beamSentences, TrainOptions{Rounds: 50, Dev: beamSentences, Patience: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// accuracy on training sentences stops improving long before 50 epochs
+	if !stats.Stopped || len(stats.Epochs) != stats.BestEpoch+2 {
+		t.Fatalf("training isn't stopped early: %+v", stats)
+	}
+	best := stats.Epochs[stats.BestEpoch-1].DevAccuracy
+	for _, epoch := range stats.Epochs {
+		if epoch.DevAccuracy > best {
+			t.Fatalf("epoch %d isn't the best one: %+v", stats.BestEpoch, stats.Epochs)
+		}
+	}
+	if acc := taggingAccuracy(tagger, beamSentences); acc != best {
+		t.Fatalf("model of the best epoch isn't restored: %f != %f", acc, best)
+	}
+	if tagger.Training.Rounds != stats.BestEpoch || tagger.Training.Params["best_epoch"] == "" {
+		t.Fatalf("wrong training info: %+v", tagger.Training)
+	}
+
+	// continued training counts epochs from the start
+	stats, err = tagger.trainRounds(beamSentences, TrainOptions{Rounds: tagger.epoch + 3, Dev: beamSentences, Patience: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Epochs) == 0 || stats.BestEpoch <= stats.Epochs[0].Epoch-1 || stats.Epochs[0].Epoch <= 1 {
+		t.Fatalf("wrong stats of continued training: %+v", stats)
+	}
+}
+
+func TestParallelTraining(t *testing.T) {
+	train := func() *PerceptronTagger {
+		tagger, _ := NewPerceptronTagger(TaggerConfig{})
+		stats, err := tagger.TrainWithOptions(beamSentences, TrainOptions{Rounds: 10, Seed: 1, Workers: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stats.Epochs) != 10 || stats.Epochs[9].Tokens != tagger.Training.Tokens {
+			t.Fatalf("wrong training stats: %+v", stats)
+		}
+		return tagger
+	}
+	tagger := train()
+	if !reflect.DeepEqual(tagger.Model.ExportWeights(), train().Model.ExportWeights()) {
+		t.Fatal("parallel training with the same seed isn't reproducible")
+	}
+	if tagger.Training.Params["workers"] != "3" {
+		t.Fatalf("count of workers isn't saved in training info: %v", tagger.Training.Params)
+	}
+	if acc := taggingAccuracy(tagger, beamSentences); acc < 0.85 {
+		t.Fatalf("accuracy on training sentences is too low: %.3f", acc)
+	}
+
+	beam, _ := NewPerceptronTagger(TaggerConfig{BeamWidth: 4})
+	beam.TrainWithOptions(beamSentences, TrainOptions{Rounds: 10, Workers: 2})
+	if acc := taggingAccuracy(beam, beamSentences); acc < 0.85 {
+		t.Fatalf("accuracy of parallel beam training is too low: %.3f", acc)
+	}
+}
+
+// Random sentences of patterns of 6 tags with `words` distinct words per tag
+func makeRandomSentences(count, words int, seed int64) []WordsTags {
+	rnd := rand.New(rand.NewSource(seed))
+	patterns := [][]string{
+		{"DT", "JJ", "NN", "VBZ", "RB", "."},
+		{"PRP", "VBD", "DT", "NN", "IN", "DT", "NN", "."},
+		{"NNS", "VBP", "JJ", "NNS", "."},
+	}
+	sentences := make([]WordsTags, count)
+	for i := range sentences {
+		for _, tag := range patterns[rnd.Intn(len(patterns))] {
+			word := tag
+			if tag != "." {
+				word = tag + strconv.Itoa(rnd.Intn(words))
+			}
+			sentences[i].Words = append(sentences[i].Words, word)
+			sentences[i].Tags = append(sentences[i].Tags, tag)
+		}
+	}
+	return sentences
+}
+
+func BenchmarkTrainParallel(b *testing.B) {
+	sentences := makeRandomSentences(20000, 2000, 1)
+	tokens := 0
+	for _, sent := range sentences {
+		tokens += len(sent.Words)
+	}
+	for _, workers := range []int{1, 2, 4} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				// all words are trained by model
+				tagger, _ := NewPerceptronTagger(TaggerConfig{})
+				tagger.FrequencyThreshold = len(sentences)
+				if _, err := tagger.TrainWithOptions(sentences, TrainOptions{Rounds: 2, Workers: workers}); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(2*tokens*b.N)/time.Since(start).Seconds(), "tokens/s")
+		})
+	}
+}
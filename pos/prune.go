@@ -0,0 +1,99 @@
+package pos
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/korobool/nlp4go/ml"
+	"io"
+	"text/tabwriter"
+)
+
+// Settings of post-training reduction of model size
+type PruneSettings struct {
+	// Weights with absolute value below Threshold are dropped
+	Threshold float64
+	// Features that took part in less than MinCount updates are dropped
+	// (counts aren't known for imported models, don't set it for them)
+	MinCount int
+	// Precision of saved weights
+	Precision ml.Precision
+}
+
+// Size and accuracy of model reduced with settings
+type PruneResult struct {
+	Settings PruneSettings
+	// Count of features with weights and count of non-zero weights
+	Features int
+	Weights  int
+	// Size of saved model in bytes (with Compression of tagger)
+	Size     int
+	Accuracy float64
+}
+
+// PruneModel reduces size of trained model, returns count of removed features
+func (t *PerceptronTagger) PruneModel(settings PruneSettings) int {
+	removed := t.Model.Prune(settings.Threshold, settings.MinCount)
+	t.Model.Quantize(settings.Precision)
+	return removed
+}
+
+// PruneReport reduces copies of model with every settings and measures
+// their size and accuracy on held-out sentences. The first result is for
+// the original model (with zero settings), the model itself isn't changed
+func (t *PerceptronTagger) PruneReport(heldout []WordsTags, settings []PruneSettings) ([]PruneResult, error) {
+
+	var original bytes.Buffer
+	if err := t.WriteModel(&original); err != nil {
+		return nil, err
+	}
+	results := make([]PruneResult, 0, len(settings)+1)
+	for i, s := range append([]PruneSettings{{}}, settings...) {
+		tagger, err := NewPerceptronTagger(TaggerConfig{Tokenizer: t.tokenizer, BeamWidth: t.BeamWidth})
+		if err != nil {
+			return nil, err
+		}
+		if err := tagger.ReadModel(bytes.NewReader(original.Bytes())); err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			tagger.PruneModel(s)
+		}
+
+		var buf bytes.Buffer
+		if err := tagger.WriteModel(&buf); err != nil {
+			return nil, err
+		}
+		result := PruneResult{Settings: s, Size: buf.Len(), Accuracy: taggingAccuracy(tagger, heldout)}
+		result.Features, result.Weights = tagger.Model.Size()
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// WritePruneReport writes results of PruneReport as a table
+func WritePruneReport(w io.Writer, results []PruneResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "threshold\tmin count\tprecision\tfeatures\tweights\tsize, KB\taccuracy\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%g\t%d\t%s\t%d\t%d\t%.1f\t%.4f\t\n", r.Settings.Threshold, r.Settings.MinCount,
+			r.Settings.Precision, r.Features, r.Weights, float64(r.Size)/1024, r.Accuracy)
+	}
+	return tw.Flush()
+}
+
+// Returns share of words of sentences tagged correctly
+func taggingAccuracy(tagger interface{ TagWords([]string) []string }, sentences []WordsTags) float64 {
+	var correct, total int
+	for _, sent := range sentences {
+		for i, tag := range tagger.TagWords(sent.Words) {
+			if tag == sent.Tags[i] {
+				correct++
+			}
+			total++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(correct) / float64(total)
+}
@@ -0,0 +1,41 @@
+package pos
+
+import (
+	"bytes"
+	"github.com/korobool/nlp4go/ml"
+	"strings"
+	"testing"
+)
+
+func TestPruneReport(t *testing.T) {
+	tagger, _ := NewPerceptronTagger(TaggerConfig{})
+	tagger.Train(append([]WordsTags(nil), beamSentences...), 5, nil)
+	original, _ := tagger.Model.Size()
+
+	results, err := tagger.PruneReport(beamSentences, []PruneSettings{
+		{Threshold: 0.1, Precision: ml.PrecisionFloat32},
+		{Threshold: 0.5, MinCount: 2, Precision: ml.PrecisionInt8},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || results[0].Features != original {
+		t.Fatalf("wrong results: %+v", results)
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i].Size >= results[i-1].Size || results[i].Weights > results[i-1].Weights {
+			t.Fatalf("model isn't reduced: %+v", results)
+		}
+	}
+	if features, _ := tagger.Model.Size(); features != original {
+		t.Fatal("report changed the model")
+	}
+
+	var buf bytes.Buffer
+	if err := WritePruneReport(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 4 || !strings.Contains(lines[3], "int8") {
+		t.Fatalf("wrong report:\n%s", buf.String())
+	}
+}
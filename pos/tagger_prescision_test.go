@@ -6,6 +6,7 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"github.com/korobool/nlp4go/eval"
 	"github.com/korobool/nlp4go/pos"
 	"github.com/korobool/nlp4go/tokenize"
 	"github.com/korobool/nlp4go/utils"
@@ -121,6 +122,56 @@ func TestPoSTaggerQuality(t *testing.T) {
 
 }
 
+func TestPoSTaggerReport(t *testing.T) {
+
+	posTagger, err := pos.NewPerceptronTagger(pos.TaggerConfig{
+		LoadModel: true,
+		ModelPath: filepath.Join(TmpPath, MODEL_FILE_NAME),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create POS tagger: %v", err)
+	}
+	train, err := parseWordsTagsFile(filepath.Join(TmpPath, trainFileName))
+	if err != nil {
+		t.Fatalf("Failed to parse train file: %v", err)
+	}
+	validate, err := parseWordsTagsFile(filepath.Join(TmpPath, validateFileName))
+	if err != nil {
+		t.Fatalf("Failed to parse validation file: %v", err)
+	}
+
+	report, err := eval.EvaluateTagger(posTagger, validate, eval.Options{Known: eval.KnownWords(train)})
+	if err != nil {
+		t.Fatalf("Failed to evaluate tagger: %v", err)
+	}
+	// report counts the same tags as TestPoSTaggerQuality
+	totalTags, guessedTags := 0, 0
+	for _, wt := range validate {
+		tags := posTagger.TagWords(wt.Words)
+		totalTags += len(wt.Tags)
+		for index, knownTag := range wt.Tags {
+			if knownTag == tags[index] {
+				guessedTags += 1
+			}
+		}
+	}
+	if report.Overall.Tokens != totalTags || report.Overall.Correct != guessedTags {
+		t.Fatalf("Report overall accuracy %+v differs from total: %d, guessed: %d", report.Overall, totalTags, guessedTags)
+	}
+	if report.Known.Tokens+report.Unknown.Tokens != totalTags || report.Known.Correct+report.Unknown.Correct != guessedTags {
+		t.Fatalf("Known %+v and unknown %+v accuracy don't sum to overall one", report.Known, report.Unknown)
+	}
+	if len(report.Tags) == 0 || report.MacroF1 <= 0 {
+		t.Fatalf("Report has no tag scores")
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteText(&buf); err != nil {
+		t.Fatalf("Failed to write report: %v", err)
+	}
+	t.Log("\n" + buf.String())
+}
+
 func trainModel(tmpPath, trainFileName string) error {
 
 	trainFilePath := filepath.Join(tmpPath, trainFileName)
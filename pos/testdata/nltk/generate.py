@@ -0,0 +1,128 @@
+# Generates averaged perceptron tagger fixture: model files in the layout
+# of nltk_data/taggers/averaged_perceptron_tagger_eng and tags of sample
+# sentences, both produced by nltk.tag.PerceptronTagger:
+#
+#   pip install nltk==3.9.1 && cd pos/testdata/nltk && python3 generate.py
+#
+# `python3 generate.py --port` produces the same files by port.py without
+# NLTK, such fixture only checks that tagging follows the port
+import json
+import random
+import sys
+
+TRAIN = """The/DT cat/NN sat/VBD on/IN the/DT mat/NN ./.
+A/DT dog/NN was/VBD running/VBG in/IN the/DT park/NN ./.
+She/PRP quickly/RB read/VBD the/DT long-awaited/JJ book/NN ./.
+They/PRP have/VBP been/VBN working/VBG since/IN 1999/CD ./.
+The/DT old/JJ man/NN gave/VBD 42/CD apples/NNS to/TO the/DT children/NNS ./.
+He/PRP is/VBZ reading/VBG a/DT newspaper/NN ./.
+We/PRP will/MD visit/VB Paris/NNP next/JJ year/NN ./.
+The/DT quick/JJ brown/JJ fox/NN jumps/VBZ over/IN the/DT lazy/JJ dog/NN ./.
+Prices/NNS rose/VBD sharply/RB in/IN 2008/CD ./.
+The/DT company/NN reported/VBD strong/JJ earnings/NNS ./.
+John/NNP likes/VBZ green/JJ tea/NN and/CC black/JJ coffee/NN ./.
+Her/PRP$ brother-in-law/NN plays/VBZ the/DT guitar/NN ./.
+The/DT children/NNS are/VBP playing/VBG outside/RB ./.
+It/PRP was/VBD a/DT beautiful/JJ day/NN ./.
+Many/JJ people/NNS visited/VBD the/DT museum/NN yesterday/NN ./.""".split("\n")
+
+# Words of generated sentences, frequent unambiguous words ("the", "in",
+# ".") get into tagdict, ambiguous ones ("fish", "that", "watch") don't
+WORDS = {
+    "DT": ["the", "a", "this", "every"],
+    "JJ": ["old", "green", "quick", "lazy", "small", "red", "happy", "strong"],
+    "NN": ["cat", "dog", "man", "book", "park", "city", "teacher", "car", "river", "house", "fish", "watch"],
+    "NNS": ["cats", "dogs", "books", "children", "apples", "students"],
+    "VBD": ["saw", "found", "liked", "visited", "watched", "painted"],
+    "VBZ": ["likes", "sees", "visits", "watches"],
+    "VBP": ["fish", "like", "see", "watch"],
+    "VB": ["see", "watch", "visit", "fish"],
+    "IN": ["in", "on", "near", "with"],
+    "PRP": ["he", "she", "they"],
+    "RB": ["quickly", "slowly", "often"],
+    "CD": ["3", "12", "1999", "250"],
+}
+
+PATTERNS = [
+    "DT JJ NN VBD DT NN IN DT NN .",
+    "PRP VBD DT JJ NNS RB .",
+    "DT NNS VBP DT NN .",
+    "PRP VBZ NNS CC/and NNS .",
+    "DT NN VBD CD NNS IN DT NN .",
+    "PRP MD/will VB DT NN .",
+    "PRP VBD IN/that DT NN VBD RB .",
+    "DT/that NN VBZ JJ/old .",
+]
+
+SAMPLE = [
+    "The lazy cat was reading a long book in the park .",
+    "Many children visited Paris in 1999 .",
+    "She plays green guitar and he likes strong coffee .",
+    "The brother-in-law reported 7 apples yesterday .",
+    "Über cafés sold 300 croissants quickly .",
+    "They fish in the river near that house .",
+    "He saw that the teacher found a fish .",
+    "Every student will watch the red watch .",
+]
+
+
+def make_sentences(count):
+    sentences = [[tuple(p.rsplit("/", 1)) for p in line.split()] for line in TRAIN]
+    rnd = random.Random(1)
+    for _ in range(count):
+        sentence = []
+        for item in rnd.choice(PATTERNS).split():
+            if item == ".":
+                sentence.append((".", "."))
+                continue
+            tag, _, word = item.partition("/")
+            sentence.append((word or rnd.choice(WORDS[tag]), tag))
+        word, tag = sentence[0]
+        sentence[0] = (word[0].upper() + word[1:], tag)
+        sentences.append(sentence)
+    return sentences
+
+
+def train_nltk(sentences):
+    from nltk.tag.perceptron import PerceptronTagger
+
+    tagger = PerceptronTagger(load=False)
+    tagger.train(sentences, nr_iter=5)
+
+    def tag(tokens):
+        return [t for _, t in tagger.tag(tokens)]
+
+    return tagger.model.weights, tagger.tagdict, tagger.classes, tag
+
+
+def train_port(sentences):
+    import port
+
+    model, tagdict = port.train(sentences)
+
+    def tag(tokens):
+        return port.tag(model, tagdict, tokens)
+
+    return model.weights, tagdict, model.classes, tag
+
+
+def main():
+    random.seed(1)
+    sentences = make_sentences(150)
+    train = train_port if "--port" in sys.argv[1:] else train_nltk
+    weights, tagdict, classes, tag = train(sentences)
+
+    prefix = "averaged_perceptron_tagger_eng"
+    with open(prefix + ".weights.json", "w") as f:
+        json.dump(weights, f, ensure_ascii=False, sort_keys=True)
+    with open(prefix + ".tagdict.json", "w") as f:
+        json.dump(tagdict, f, ensure_ascii=False, sort_keys=True)
+    with open(prefix + ".classes.json", "w") as f:
+        json.dump(sorted(classes), f)
+    with open("expected_tags.json", "w") as f:
+        expected = [{"words": s.split(), "tags": tag(s.split())} for s in SAMPLE]
+        json.dump(expected, f, ensure_ascii=False, indent=1)
+
+
+if __name__ == "__main__":
+    main()
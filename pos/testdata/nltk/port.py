@@ -0,0 +1,141 @@
+# Hand-written port of training and tagging of nltk/tag/perceptron.py
+# (NLTK 3.9.1), used by `generate.py --port` where NLTK isn't installed.
+# It isn't checked against NLTK, fixture must be generated by NLTK itself
+import random
+from collections import defaultdict
+
+START = ["-START-", "-START2-"]
+END = ["-END-", "-END2-"]
+
+
+class AveragedPerceptron:
+    def __init__(self):
+        self.weights = {}
+        self.classes = set()
+        self._totals = defaultdict(int)
+        self._tstamps = defaultdict(int)
+        self.i = 0
+
+    def predict(self, features):
+        scores = defaultdict(float)
+        for feat, value in features.items():
+            if feat not in self.weights or value == 0:
+                continue
+            for label, weight in self.weights[feat].items():
+                scores[label] += value * weight
+        return max(self.classes, key=lambda label: (scores[label], label))
+
+    def update(self, truth, guess, features):
+        def upd_feat(c, f, w, v):
+            param = (f, c)
+            self._totals[param] += (self.i - self._tstamps[param]) * w
+            self._tstamps[param] = self.i
+            self.weights[f][c] = w + v
+
+        self.i += 1
+        if truth == guess:
+            return
+        for f in features:
+            weights = self.weights.setdefault(f, {})
+            upd_feat(truth, f, weights.get(truth, 0.0), 1.0)
+            upd_feat(guess, f, weights.get(guess, 0.0), -1.0)
+
+    def average_weights(self):
+        for feat, weights in self.weights.items():
+            new_feat_weights = {}
+            for clas, weight in weights.items():
+                param = (feat, clas)
+                total = self._totals[param]
+                total += (self.i - self._tstamps[param]) * weight
+                averaged = round(total / self.i, 3)
+                if averaged:
+                    new_feat_weights[clas] = averaged
+            self.weights[feat] = new_feat_weights
+
+
+def normalize(word):
+    if "-" in word and word[0] != "-":
+        return "!HYPHEN"
+    if word.isdigit() and len(word) == 4:
+        return "!YEAR"
+    if word and word[0].isdigit():
+        return "!DIGITS"
+    return word.lower()
+
+
+def get_features(i, word, context, prev, prev2):
+    def add(name, *args):
+        features[" ".join((name,) + tuple(args))] += 1
+
+    i += len(START)
+    features = defaultdict(int)
+    add("bias")
+    add("i suffix", word[-3:])
+    add("i pref1", word[0] if word else "")
+    add("i-1 tag", prev)
+    add("i-2 tag", prev2)
+    add("i tag+i-2 tag", prev, prev2)
+    add("i word", context[i])
+    add("i-1 tag+i word", prev, context[i])
+    add("i-1 word", context[i - 1])
+    add("i-1 suffix", context[i - 1][-3:])
+    add("i-2 word", context[i - 2])
+    add("i+1 word", context[i + 1])
+    add("i+1 suffix", context[i + 1][-3:])
+    add("i+2 word", context[i + 2])
+    return features
+
+
+def make_tagdict(sentences, freq_thresh=20, ambiguity_thresh=0.97):
+    counts = defaultdict(lambda: defaultdict(int))
+    classes = set()
+    for sentence in sentences:
+        for word, tag in sentence:
+            counts[word][tag] += 1
+            classes.add(tag)
+    tagdict = {}
+    for word, tag_freqs in counts.items():
+        tag, mode = max(tag_freqs.items(), key=lambda item: item[1])
+        n = sum(tag_freqs.values())
+        if n >= freq_thresh and (mode / n) >= ambiguity_thresh:
+            tagdict[word] = tag
+    return tagdict, classes
+
+
+def tag(model, tagdict, tokens):
+    prev, prev2 = START
+    output = []
+    context = START + [normalize(w) for w in tokens] + END
+    for i, word in enumerate(tokens):
+        t = tagdict.get(word)
+        if not t:
+            features = get_features(i, word, context, prev, prev2)
+            t = model.predict(features)
+        output.append(t)
+        prev2 = prev
+        prev = t
+    return output
+
+
+def train(sentences, nr_iter=5):
+    """Mirrors PerceptronTagger.train, returns weights, tagdict and classes."""
+    sentences = list(sentences)
+    tagdict, classes = make_tagdict(sentences)
+    model = AveragedPerceptron()
+    model.classes = classes
+    for _ in range(nr_iter):
+        for sentence in sentences:
+            words, tags = zip(*sentence)
+            prev, prev2 = START
+            context = START + [normalize(w) for w in words] + END
+            for i, word in enumerate(words):
+                guess = tagdict.get(word)
+                if not guess:
+                    feats = get_features(i, word, context, prev, prev2)
+                    guess = model.predict(feats)
+                    model.update(tags[i], guess, feats)
+                prev2 = prev
+                prev = guess
+        random.shuffle(sentences)
+    model.average_weights()
+    return model, tagdict
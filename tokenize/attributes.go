@@ -0,0 +1,120 @@
+package tokenize
+
+import (
+	"fmt"
+	"github.com/korobool/nlp4go/core"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// `Attribute` is a named typed extension attribute of Token
+// (entity ID, sentiment, custom flags, etc.) stored in Token.Ext.
+// Pipeline components declare attributes they produce or consume
+// with RegisterAttribute:
+//
+//	var EntityID = tokenize.MustRegisterAttribute("entity_id", "")
+//	EntityID.Set(token, "Q42")
+//	id := EntityID.Get(token)
+type Attribute[T any] struct {
+	key     core.Key[T]
+	Default T
+}
+
+// Registered attribute with its value type
+type attrInfo struct {
+	attr interface{}
+	typ  reflect.Type
+}
+
+var (
+	attrMutex    sync.RWMutex
+	attrRegistry = map[core.MetaDataKey]attrInfo{}
+)
+
+// RegisterAttribute declares extension attribute `name` of type T with
+// default value `def` returned for tokens where attribute is not set.
+// Registering the same name again returns already declared attribute
+// if its type is T and an error otherwise
+func RegisterAttribute[T any](name string, def T) (*Attribute[T], error) {
+	attrMutex.Lock()
+	defer attrMutex.Unlock()
+
+	key := core.MetaDataKey(name)
+	if info, ok := attrRegistry[key]; ok {
+		if typed, ok := info.attr.(*Attribute[T]); ok {
+			return typed, nil
+		}
+		return nil, fmt.Errorf("attribute %q is already registered with another type", name)
+	}
+	attr := &Attribute[T]{key: core.NewKey[T](key), Default: def}
+	attrRegistry[key] = attrInfo{attr, reflect.TypeOf((*T)(nil)).Elem()}
+	return attr, nil
+}
+
+// MustRegisterAttribute is like RegisterAttribute but panics
+// if attribute can't be registered
+func MustRegisterAttribute[T any](name string, def T) *Attribute[T] {
+	attr, err := RegisterAttribute(name, def)
+	if err != nil {
+		panic(err)
+	}
+	return attr
+}
+
+// Returns sorted names of all registered attributes
+func RegisteredAttributes() []string {
+	attrMutex.RLock()
+	defer attrMutex.RUnlock()
+
+	names := make([]string, 0, len(attrRegistry))
+	for key := range attrRegistry {
+		names = append(names, string(key))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Returns type of registered attribute `name`
+func AttributeType(name string) (reflect.Type, bool) {
+	attrMutex.RLock()
+	defer attrMutex.RUnlock()
+
+	info, ok := attrRegistry[core.MetaDataKey(name)]
+	return info.typ, ok
+}
+
+// Returns name of attribute
+func (a *Attribute[T]) Name() string {
+	return string(a.key.Name())
+}
+
+// Returns attribute value of token or default value if it's not set
+func (a *Attribute[T]) Get(t *Token) T {
+	if val, ok := a.Lookup(t); ok {
+		return val
+	}
+	return a.Default
+}
+
+// Returns attribute value of token and `true` if it's set
+func (a *Attribute[T]) Lookup(t *Token) (T, bool) {
+	if t.Ext == nil {
+		var val T
+		return val, false
+	}
+	return a.key.Get(t.Ext)
+}
+
+// Sets attribute value of token
+func (a *Attribute[T]) Set(t *Token, val T) {
+	a.key.Set(t.Meta(), val)
+}
+
+// Deletes attribute value of token
+func (a *Attribute[T]) Del(t *Token) bool {
+	if t.Ext == nil {
+		return false
+	}
+	return a.key.Del(t.Ext)
+}
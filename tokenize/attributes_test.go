@@ -0,0 +1,78 @@
+package tokenize
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var (
+	testEntityAttr = MustRegisterAttribute("test_entity_id", "")
+	testScoreAttr  = MustRegisterAttribute("test_sentiment", 0.0)
+)
+
+func TestAttributeRegistry(t *testing.T) {
+	attr, err := RegisterAttribute("test_entity_id", "default")
+	if err != nil || attr != testEntityAttr {
+		t.Fatalf("Expected already registered attribute, got %v, %v", attr, err)
+	}
+	if _, err := RegisterAttribute("test_entity_id", 1); err == nil {
+		t.Fatal("Expected error for attribute registered with another type")
+	}
+	if typ, ok := AttributeType("test_sentiment"); !ok || typ != reflect.TypeOf(0.0) {
+		t.Fatalf("Unexpected attribute type: %v", typ)
+	}
+	if _, ok := AttributeType("unknown"); ok {
+		t.Fatal("Expected unknown attribute")
+	}
+}
+
+func TestTokenAttributes(t *testing.T) {
+	token := NewToken([]rune("Kyiv"), 0, 4)
+
+	if val := testScoreAttr.Get(token); val != 0.0 || token.Ext != nil {
+		t.Fatalf("Expected default value without allocation, got %v", val)
+	}
+	testEntityAttr.Set(token, "Q1899")
+	testScoreAttr.Set(token, 0.75)
+
+	if val, ok := testEntityAttr.Lookup(token); !ok || val != "Q1899" {
+		t.Fatalf("Unexpected attribute value: %v", val)
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &Token{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if testEntityAttr.Get(decoded) != "Q1899" || testScoreAttr.Get(decoded) != 0.75 {
+		t.Fatalf("Attributes are lost after JSON marshaling: %s", data)
+	}
+
+	if !testScoreAttr.Del(token) || testScoreAttr.Del(token) {
+		t.Fatal("Unexpected result of attribute deletion")
+	}
+}
+
+func TestAttributesContractions(t *testing.T) {
+	token := NewToken([]rune("don't"), 0, 5)
+	token.HasApostrophe = true
+	testEntityAttr.Set(token, "neg")
+
+	tokens, ok := NewEnglishContractions().Expand(token)
+	if !ok || len(tokens) != 2 {
+		t.Fatalf("Expected contraction to be expanded: %v", tokens)
+	}
+	for _, tok := range tokens {
+		if testEntityAttr.Get(tok) != "neg" {
+			t.Fatalf("Attribute is lost after contraction expansion: %v", tok)
+		}
+	}
+	testEntityAttr.Set(tokens[0], "changed")
+	if testEntityAttr.Get(tokens[1]) != "neg" {
+		t.Fatal("Expanded tokens share attributes")
+	}
+}
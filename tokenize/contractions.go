@@ -65,6 +65,8 @@ func (c *EnglishContractions) splitToken(re *regexp.Regexp, token *Token) ([]*To
 		}
 		tokens[0].SetText(token.Runes[:boundL])
 		tokens[1].SetText(token.Runes[boundL:])
+		tokens[0].InheritExt(token)
+		tokens[1].InheritExt(token)
 
 		return tokens, true
 	}
@@ -0,0 +1,19 @@
+package tokenize
+
+import "github.com/korobool/nlp4go/core"
+
+// Returns words of tokens
+func Words(tokens []*Token) []string {
+	words := make([]string, len(tokens))
+	for i, token := range tokens {
+		words[i] = token.Word
+	}
+	return words
+}
+
+// MatchTokens finds leftmost-longest non-overlapping occurrences of
+// dictionary patterns in tokens. From and To of matches are token indexes,
+// rune offsets are tokens[From].Pos and tokens[To-1].PosEnd()
+func MatchTokens(m *core.WordMatcher, tokens []*Token) []core.ACMatch {
+	return m.FindLongest(Words(tokens))
+}
@@ -0,0 +1,26 @@
+package tokenize
+
+import (
+	"github.com/korobool/nlp4go/core"
+	"testing"
+)
+
+func TestMatchTokens(t *testing.T) {
+	matcher := core.NewWordMatcher([]core.ACPattern{
+		{Text: "New York", Payload: "GPE"},
+		{Text: "New York Times", Payload: "ORG"},
+	}, core.ACOptions{CaseInsensitive: true})
+
+	tokens := NewTBWordTokenizer(true, true, nil).Tokenize("I read the new york times in New York.")
+	matches := MatchTokens(matcher, tokens)
+
+	if len(matches) != 2 {
+		t.Fatalf("Expected 2 matches, got %v", matches)
+	}
+	if matches[0].Payload != "ORG" || tokens[matches[0].From].Pos != 11 || tokens[matches[0].To-1].PosEnd() != 25 {
+		t.Fatalf("Unexpected first match: %v", matches[0])
+	}
+	if matches[1].Payload != "GPE" || matches[1].From != 7 || matches[1].To != 9 {
+		t.Fatalf("Unexpected second match: %v", matches[1])
+	}
+}
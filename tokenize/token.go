@@ -1,6 +1,9 @@
 package tokenize
 
-import "fmt"
+import (
+	"fmt"
+	"github.com/korobool/nlp4go/core"
+)
 
 type Token struct {
 	Runes         []rune `json:"runes"`
@@ -11,6 +14,8 @@ type Token struct {
 	IsQuoteEnd    bool   `json:"is_quote_end"`
 	IsEllipsis    bool   `json:"is_ellipsis"`
 	HasApostrophe bool   `json:"has_apostrophe"`
+	// Extension attributes, see Attribute
+	Ext *core.MetaData `json:"ext,omitempty"`
 }
 
 func NewToken(str []rune, posStart, length int) *Token {
@@ -26,6 +31,22 @@ func (t *Token) SetText(text []rune) {
 	t.Word = string(text)
 }
 
+// Returns extension attributes storage allocating it if needed
+func (t *Token) Meta() *core.MetaData {
+	if t.Ext == nil {
+		t.Ext = core.NewMetaData()
+	}
+	return t.Ext
+}
+
+// Copies extension attributes of `from` token,
+// used when token is derived from another one (e.g. contraction parts)
+func (t *Token) InheritExt(from *Token) {
+	if from.Ext != nil {
+		t.Ext = from.Ext.Clone()
+	}
+}
+
 func (t *Token) String() string {
 	return fmt.Sprintf("<word=%s pos_tag=%s start=%d end=%d q_s=%v q_e=%v e=%v a=%v>",
 		t.Word,